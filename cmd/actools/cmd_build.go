@@ -0,0 +1,283 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/docker"
+)
+
+var (
+	buildJobs  int
+	buildForce bool
+)
+
+func init() {
+	CmdBuild.PersistentFlags().IntVarP(&buildJobs, "jobs", "j", 4, "Número de imágenes que se construyen a la vez")
+	CmdBuild.PersistentFlags().BoolVar(&buildForce, "force", false, "Construye las imágenes aunque su contenido no haya cambiado")
+	CmdRoot.AddCommand(CmdBuild)
+}
+
+var CmdBuild = &cobra.Command{
+	Use:   "build [image]...",
+	Short: "Construye las imágenes del proyecto declaradas en actools.yml.",
+	Long: `Construye las imágenes del proyecto declaradas en actools.yml.
+
+Sin argumentos construye todas. Las imágenes que parten de otra del proyecto
+esperan a que termine la suya y el resto se construyen en paralelo. Solo se
+construye la etiqueta latest, las que parten de otra etiqueta o digest usan la
+versión publicada en el registro.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if buildJobs < 1 {
+			return errors.Errorf("invalid number of jobs: %d", buildJobs)
+		}
+
+		names, err := selectProjectImages(args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		deps, err := projectImageDeps(names)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// Parallel builds would mix the output of every one of them.
+		var options []docker.BuildOption
+		if buildJobs > 1 && len(deps) > 1 {
+			options = append(options, docker.WithQuietBuild())
+		}
+		if buildForce {
+			options = append(options, docker.WithForceBuild())
+		}
+		for _, desc := range config.Settings.Images {
+			options = append(options, docker.WithTrackedBases(projectImage(desc)))
+		}
+
+		return errors.Trace(runGraph(deps, buildJobs, func(name string) error {
+			desc := config.Settings.Images[name]
+			context, dockerfile := projectImageContext(desc)
+			opts := append([]docker.BuildOption{docker.WithBuildTarget(desc.Target)}, options...)
+			for arg, value := range desc.Args {
+				opts = append(opts, docker.WithBuildArg(arg, value))
+			}
+			if err := projectImage(desc).Build(context, dockerfile, opts...); err != nil {
+				return errors.Wrapf(err, "image %s", name)
+			}
+			return nil
+		}))
+	},
+}
+
+// selectProjectImages validates the names of the images or returns all of them
+// if none is requested.
+func selectProjectImages(names []string) ([]string, error) {
+	if len(config.Settings.Images) == 0 {
+		return nil, errors.Errorf("no images declared in actools.yml")
+	}
+	// Dependencies are searched in all the images, not only the selected ones.
+	for name, desc := range config.Settings.Images {
+		if desc == nil {
+			return nil, errors.Errorf("image %s has no settings in actools.yml", name)
+		}
+	}
+
+	if len(names) == 0 {
+		for name := range config.Settings.Images {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		desc, ok := config.Settings.Images[name]
+		if !ok {
+			return nil, errors.Errorf("unknown image: %s", name)
+		}
+		if desc.Name == "" {
+			return nil, errors.Errorf("image %s has no name in actools.yml", name)
+		}
+	}
+
+	return names, nil
+}
+
+// projectImageDeps reads the Dockerfiles to find the images that start from
+// other images of the project. Those are added to the build if they were not
+// requested.
+func projectImageDeps(names []string) (map[string][]string, error) {
+	byName := make(map[string]string)
+	for name, desc := range config.Settings.Images {
+		byName[desc.Name] = name
+	}
+
+	deps := make(map[string][]string)
+	pending := append([]string{}, names...)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := deps[name]; ok {
+			continue
+		}
+
+		desc := config.Settings.Images[name]
+		_, dockerfile := projectImageContext(desc)
+		bases, err := docker.DockerfileBases(dockerfile, desc.Args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "image %s", name)
+		}
+
+		deps[name] = []string{}
+		for _, base := range bases {
+			repo, tag := splitBaseTag(base)
+			dep, ok := byName[repo]
+			if !ok || dep == name {
+				continue
+			}
+			// Only the latest tag is built locally, docker pulls other tags or
+			// digests from the registry instead.
+			if tag != docker.DefaultTag {
+				log.WithFields(log.Fields{
+					"image": name,
+					"base":  base,
+				}).Warning("Image starts from a published version of another image of the project instead of the local build")
+				continue
+			}
+			deps[name] = append(deps[name], dep)
+			pending = append(pending, dep)
+		}
+	}
+
+	return deps, nil
+}
+
+// splitBaseTag separates the repository of an image reference from its tag or
+// digest. References without them use the latest tag.
+func splitBaseTag(ref string) (string, string) {
+	if idx := strings.Index(ref, "@"); idx != -1 {
+		return ref[:idx], ref[idx:]
+	}
+	if idx := strings.LastIndex(ref, ":"); idx > strings.LastIndex(ref, "/") {
+		return ref[:idx], ref[idx+1:]
+	}
+	return ref, docker.DefaultTag
+}
+
+func projectImageContext(desc *config.Image) (string, string) {
+	context := desc.Context
+	if context == "" {
+		context = "."
+	}
+	dockerfile := desc.Dockerfile
+	if dockerfile == "" {
+		dockerfile = filepath.Join(context, "Dockerfile")
+	}
+	return context, dockerfile
+}
+
+func projectImage(desc *config.Image) *docker.ImageManager {
+	return docker.Image(path.Dir(desc.Name), path.Base(desc.Name))
+}
+
+// runGraph calls fn for every node after all its dependencies finished
+// successfully. Independent nodes run in parallel up to jobs at a time.
+func runGraph(deps map[string][]string, jobs int, fn func(name string) error) error {
+	if err := checkCycles(deps); err != nil {
+		return errors.Trace(err)
+	}
+
+	done := make(map[string]chan struct{})
+	for name := range deps {
+		done[name] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]bool)
+	var errs []error
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+	for name := range deps {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[name])
+
+			for _, dep := range deps[name] {
+				<-done[dep]
+			}
+
+			mu.Lock()
+			for _, dep := range deps[name] {
+				if failed[dep] {
+					failed[name] = true
+				}
+			}
+			skip := failed[name]
+			mu.Unlock()
+			if skip {
+				log.WithField("name", name).Warning("Skipped because a dependency failed")
+				return
+			}
+
+			sem <- struct{}{}
+			err := fn(name)
+			<-sem
+
+			if err != nil {
+				mu.Lock()
+				failed[name] = true
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		for _, err := range errs[1:] {
+			log.Error(err.Error())
+		}
+		return errors.Trace(errs[0])
+	}
+	return nil
+}
+
+func checkCycles(deps map[string][]string) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		switch state[name] {
+		case visiting:
+			return errors.Errorf("dependency cycle: %s", strings.Join(append(stack, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(stack, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	for name := range deps {
+		if err := visit(name, nil); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/cache"
+)
+
+var cacheCleanProject bool
+
+func init() {
+	CmdCacheClean.PersistentFlags().BoolVar(&cacheCleanProject, "project", false, "Borra solo la caché del proyecto actual")
+	CmdCache.AddCommand(CmdCacheClean)
+}
+
+var CmdCacheClean = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove the cache of all the projects or only the current one.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projects, err := cacheProjects(cacheCleanProject)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		for _, project := range projects {
+			log.WithField("project", project.Name).Info("Remove cache")
+			if err := project.Remove(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+		// Shared caches are only removed when cleaning everything.
+		if !cacheCleanProject {
+			log.Info("Remove shared caches")
+			if err := cache.RemoveShared(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/cache"
+)
+
+func init() {
+	CmdCache.AddCommand(CmdCacheExport)
+}
+
+var CmdCacheExport = &cobra.Command{
+	Use:   "export <file.tar.gz>",
+	Short: "Export the cache of the current project and the shared Go modules to a tarball. Use - to write to stdout.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := cache.CurrentProject()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := os.Stat(project.Dir); err != nil {
+			return errors.Trace(err)
+		}
+
+		var w io.Writer = os.Stdout
+		if args[0] != "-" {
+			f, err := os.Create(args[0])
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		log.WithFields(log.Fields{
+			"project": project.Name,
+			"file":    args[0],
+		}).Info("Export cache")
+
+		return errors.Trace(project.Export(w))
+	},
+}
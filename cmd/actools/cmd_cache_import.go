@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/cache"
+)
+
+func init() {
+	CmdCache.AddCommand(CmdCacheImport)
+}
+
+var CmdCacheImport = &cobra.Command{
+	Use:   "import <file.tar.gz>",
+	Short: "Import a tarball generated by export into the cache of the current project. Use - to read from stdin.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := cache.CurrentProject()
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		log.WithFields(log.Fields{
+			"project": project.Name,
+			"file":    args[0],
+		}).Info("Import cache")
+
+		if err := project.Import(r); err != nil {
+			return errors.Trace(err)
+		}
+
+		return errors.Trace(cache.MarkProject())
+	},
+}
@@ -16,7 +16,7 @@ var CmdCachePrint = &cobra.Command{
 	Use:   "print",
 	Short: "Print the directory where the artifacts of the tools are cached.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Printf("%s/.actools/cache-%s\n", config.Home(), config.ProjectName())
+		fmt.Println(config.ProjectCacheDir())
 		return nil
 	},
 }
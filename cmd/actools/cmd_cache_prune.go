@@ -0,0 +1,59 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/cache"
+)
+
+var (
+	cachePruneOlderThan string
+	cachePruneDryRun    bool
+)
+
+func init() {
+	CmdCachePrune.PersistentFlags().StringVar(&cachePruneOlderThan, "older-than", "30d", "Antigüedad mínima del último uso de la caché para borrarla")
+	CmdCachePrune.PersistentFlags().BoolVar(&cachePruneDryRun, "dry-run", false, "Muestra las cachés que se borrarían sin hacerlo")
+	CmdCache.AddCommand(CmdCachePrune)
+}
+
+var CmdCachePrune = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the old caches of projects whose directories no longer exist.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		age, err := cache.ParseAge(cachePruneOlderThan)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		projects, err := cache.Projects()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, project := range projects {
+			if !project.Orphan() || time.Since(project.LastUsed) < age {
+				continue
+			}
+
+			logger := log.WithFields(log.Fields{
+				"project":   project.Name,
+				"source":    project.Source,
+				"last-used": project.LastUsed.Format(time.RFC3339),
+			})
+			if cachePruneDryRun {
+				logger.Info("Cache would be removed")
+				continue
+			}
+			logger.Info("Remove cache")
+			if err := project.Remove(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+		return nil
+	},
+}
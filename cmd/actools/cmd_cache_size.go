@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/cache"
+)
+
+var cacheSizeProject bool
+
+func init() {
+	CmdCacheSize.PersistentFlags().BoolVar(&cacheSizeProject, "project", false, "Muestra solo la caché del proyecto actual")
+	CmdCache.AddCommand(CmdCacheSize)
+}
+
+var CmdCacheSize = &cobra.Command{
+	Use:   "size",
+	Short: "Print the size of the cache of each project and its directories.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projects, err := cacheProjects(cacheSizeProject)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tDIRECTORY\tSIZE")
+		var total int64
+		for _, project := range projects {
+			size, err := cache.Size(project.Dir)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			total += size
+			fmt.Fprintf(w, "%s\t\t%s\n", project.Name, cache.FormatSize(size))
+
+			subdirs, err := project.Subdirs()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, subdir := range subdirs {
+				size, err := cache.Size(filepath.Join(project.Dir, subdir))
+				if err != nil {
+					return errors.Trace(err)
+				}
+				fmt.Fprintf(w, "\t%s\t%s\n", subdir, cache.FormatSize(size))
+			}
+		}
+
+		shared, err := cache.Size(cache.SharedModulesDir())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		total += shared
+		fmt.Fprintf(w, "(shared)\tgo-mod\t%s\n", cache.FormatSize(shared))
+		fmt.Fprintf(w, "TOTAL\t\t%s\n", cache.FormatSize(total))
+
+		if err := w.Flush(); err != nil {
+			return errors.Trace(err)
+		}
+
+		// Without the shared cache every Go project would have its own copy of the modules.
+		all, err := cache.Projects()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		var users int64
+		for _, project := range all {
+			uses, err := project.UsesGo()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if uses {
+				users++
+			}
+		}
+		if users > 1 {
+			fmt.Printf("\nGo modules shared by %d projects, saving about %s.\n", users, cache.FormatSize(shared*(users-1)))
+		}
+
+		return nil
+	},
+}
+
+// cacheProjects returns the cache of the current project or the caches of
+// all the projects of the user.
+func cacheProjects(onlyCurrent bool) ([]*cache.Project, error) {
+	if onlyCurrent {
+		project, err := cache.CurrentProject()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []*cache.Project{project}, nil
+	}
+
+	projects, err := cache.Projects()
+	return projects, errors.Trace(err)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/containers"
+)
+
+func init() {
+	CmdRoot.AddCommand(CmdCatalog)
+}
+
+var CmdCatalog = &cobra.Command{
+	Use:   "catalog",
+	Short: "Build and publish the catalog images from the actools repository.",
+}
+
+// catalogSelection returns the requested catalog images, or all of them, with
+// their dependencies inside the catalog.
+func catalogSelection(names []string) (map[string][]string, error) {
+	if _, err := os.Stat(containers.CatalogDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("run the catalog commands from the root of the actools repository")
+		}
+		return nil, errors.Trace(err)
+	}
+
+	if len(names) == 0 {
+		for _, container := range containers.List() {
+			names = append(names, container.Image)
+		}
+	}
+
+	deps := make(map[string][]string)
+	pending := append([]string{}, names...)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := deps[name]; ok {
+			continue
+		}
+
+		container, err := containers.FindImage(name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		deps[name], err = container.CatalogDeps()
+		if err != nil {
+			return nil, errors.Wrapf(err, "image %s", name)
+		}
+		pending = append(pending, deps[name]...)
+	}
+
+	return deps, nil
+}
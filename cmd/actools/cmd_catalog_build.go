@@ -0,0 +1,109 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/containers"
+	"github.com/altipla-consulting/actools/pkg/docker"
+)
+
+var (
+	catalogBuildJobs  int
+	catalogBuildForce bool
+	catalogMultiArch  bool
+	catalogRelease    string
+)
+
+func init() {
+	CmdCatalogBuild.PersistentFlags().IntVarP(&catalogBuildJobs, "jobs", "j", 4, "Número de imágenes que se construyen a la vez")
+	CmdCatalogBuild.PersistentFlags().BoolVar(&catalogBuildForce, "force", false, "Construye las imágenes aunque su contenido no haya cambiado")
+	CmdCatalogBuild.PersistentFlags().BoolVar(&catalogMultiArch, "multi-arch", false, "Construye con buildx todas las plataformas de cada imagen y las sube al registro")
+	CmdCatalogBuild.PersistentFlags().StringVar(&catalogRelease, "release", "", "Publica también cada tag con este nombre inmutable, por ejemplo el de la build. Solo con --multi-arch")
+	CmdCatalog.AddCommand(CmdCatalogBuild)
+}
+
+var CmdCatalogBuild = &cobra.Command{
+	Use:   "build [image]...",
+	Short: "Build every tag of the catalog images, waiting for the images they start from.",
+	Long: `Build every tag of the catalog images, waiting for the images they start from.
+
+With --multi-arch every platform of the image is built with buildx and pushed
+directly to the registry as a multi-platform manifest, catalog push is not needed.
+Images with a single platform are pushed as a plain image instead, machines of
+other architectures cannot pull a manifest without their platform.
+The manifest records the hash of the content to skip the tags that did not change
+since they were published, unless --force is used. The hash includes the digest
+in the registry of the external images they start from, so a new upstream
+version of a floating tag like node:18 rebuilds the images too.
+
+With --release every tag is published too with an immutable name, for example
+go:1.21-20231019.1 and go:20231019.1 for latest, to pin the exact images of a release.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if catalogBuildJobs < 1 {
+			return errors.Errorf("invalid number of jobs: %d", catalogBuildJobs)
+		}
+		if catalogRelease != "" && !catalogMultiArch {
+			return errors.Errorf("--release needs --multi-arch, use catalog push --release instead")
+		}
+
+		deps, err := catalogSelection(args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		var options []docker.BuildOption
+		if catalogBuildJobs > 1 && len(deps) > 1 {
+			options = append(options, docker.WithQuietBuild())
+		}
+		if catalogBuildForce {
+			options = append(options, docker.WithForceBuild())
+		}
+		for _, container := range containers.List() {
+			for _, tag := range container.Tags() {
+				options = append(options, docker.WithTrackedBases(container.CatalogImage(tag)))
+			}
+		}
+
+		return errors.Trace(runGraph(deps, catalogBuildJobs, func(name string) error {
+			container, err := containers.FindImage(name)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			for _, tag := range container.Tags() {
+				opts := append([]docker.BuildOption{}, options...)
+				for arg, value := range container.BuildArgs(tag) {
+					opts = append(opts, docker.WithBuildArg(arg, value))
+				}
+
+				image := container.CatalogImage(tag)
+				if !catalogMultiArch {
+					if err := image.Build(container.BuildContext(), "", opts...); err != nil {
+						return errors.Wrapf(err, "image %s:%s", name, tag)
+					}
+					continue
+				}
+
+				platforms := make(map[string][]docker.BuildOption)
+				for _, platform := range container.BuildPlatforms() {
+					var platformOpts []docker.BuildOption
+					for arg, value := range container.PlatformArgs[platform] {
+						platformOpts = append(platformOpts, docker.WithBuildArg(arg, value))
+					}
+					platforms[platform] = platformOpts
+				}
+				if err := image.BuildMultiPlatform(container.BuildContext(), "", platforms, opts...); err != nil {
+					return errors.Wrapf(err, "image %s:%s", name, tag)
+				}
+				if catalogRelease != "" {
+					if err := image.CopyManifest(container.ReleaseTag(tag, catalogRelease)); err != nil {
+						return errors.Wrapf(err, "image %s:%s", name, tag)
+					}
+				}
+			}
+
+			return nil
+		}))
+	},
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/containers"
+	"github.com/altipla-consulting/actools/pkg/docker"
+)
+
+const composeHeader = "# Generated by `actools catalog compose`. DO NOT EDIT.\n\n"
+
+var catalogComposeOutput string
+
+func init() {
+	CmdCatalogCompose.PersistentFlags().StringVarP(&catalogComposeOutput, "output", "o", "docker-compose.yml", "Fichero donde se escribe el resultado")
+	CmdCatalog.AddCommand(CmdCatalogCompose)
+}
+
+type composeFile struct {
+	Version  string        `yaml:"version"`
+	Services yaml.MapSlice `yaml:"services"`
+}
+
+type composeService struct {
+	Image string       `yaml:"image"`
+	Build composeBuild `yaml:"build"`
+}
+
+type composeBuild struct {
+	Context string `yaml:"context"`
+}
+
+var CmdCatalogCompose = &cobra.Command{
+	Use:   "compose",
+	Short: "Generate the docker-compose.yml of the catalog images.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		compose := composeFile{Version: "3.7"}
+		for _, container := range containers.List() {
+			compose.Services = append(compose.Services, yaml.MapItem{
+				Key: container.Image,
+				Value: composeService{
+					Image: container.CatalogImage(docker.DefaultTag).String(),
+					Build: composeBuild{Context: container.BuildContext()},
+				},
+			})
+		}
+
+		content, err := yaml.Marshal(compose)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(composeHeader)
+		buf.Write(content)
+
+		if catalogComposeOutput == "-" {
+			fmt.Print(buf.String())
+			return nil
+		}
+		return errors.Trace(ioutil.WriteFile(catalogComposeOutput, buf.Bytes(), 0644))
+	},
+}
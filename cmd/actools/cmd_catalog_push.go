@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/containers"
+)
+
+var catalogPushRelease string
+
+func init() {
+	CmdCatalogPush.PersistentFlags().StringVar(&catalogPushRelease, "release", "", "Publica también cada tag con este nombre inmutable, por ejemplo el de la build")
+	CmdCatalog.AddCommand(CmdCatalogPush)
+}
+
+var CmdCatalogPush = &cobra.Command{
+	Use:   "push [image]...",
+	Short: "Push every tag of the catalog images built with catalog build.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.Offline() {
+			return errors.Errorf("cannot push images in offline mode")
+		}
+
+		deps, err := catalogSelection(args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		var names []string
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			container, err := containers.FindImage(name)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, tag := range container.Tags() {
+				if err := container.CatalogImage(tag).Push(tag); err != nil {
+					return errors.Trace(err)
+				}
+				if catalogPushRelease != "" {
+					if err := container.CatalogImage(tag).Push(container.ReleaseTag(tag, catalogPushRelease)); err != nil {
+						return errors.Trace(err)
+					}
+				}
+			}
+		}
+
+		return nil
+	},
+}
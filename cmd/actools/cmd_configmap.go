@@ -1,65 +1,151 @@
 package main
 
 import (
-	"fmt"
 	"io/ioutil"
-	"path/filepath"
+	"os"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/manifests"
 )
 
-var configMapName string
+var (
+	configMapName        string
+	configMapNamespace   string
+	configMapEnvFiles    []string
+	configMapLiterals    []string
+	configMapLabels      []string
+	configMapAnnotations []string
+	configMapHashSuffix  bool
+	configMapPatch       []string
+	configMapSpec        string
+	configMapFormat      string
+	configMapOutput      string
+)
 
 func init() {
 	CmdConfigMap.PersistentFlags().StringVar(&configMapName, "name", "", "Nombre del ConfigMap que vamos a generar")
+	CmdConfigMap.PersistentFlags().StringVar(&configMapNamespace, "namespace", "", "Namespace del ConfigMap")
+	CmdConfigMap.PersistentFlags().StringArrayVar(&configMapEnvFiles, "from-env-file", nil, "Fichero con líneas KEY=VALUE que se añaden como claves")
+	CmdConfigMap.PersistentFlags().StringArrayVar(&configMapLiterals, "from-literal", nil, "Clave con su valor en formato KEY=VALUE")
+	CmdConfigMap.PersistentFlags().StringArrayVar(&configMapLabels, "label", nil, "Etiqueta del ConfigMap en formato KEY=VALUE")
+	CmdConfigMap.PersistentFlags().StringArrayVar(&configMapAnnotations, "annotation", nil, "Anotación del ConfigMap en formato KEY=VALUE")
+	CmdConfigMap.PersistentFlags().BoolVar(&configMapHashSuffix, "hash-suffix", false, "Añade al nombre un hash del contenido para que los Deployments se actualicen al cambiar")
+	CmdConfigMap.PersistentFlags().StringArrayVar(&configMapPatch, "patch", nil, "Manifiesto cuyas referencias al ConfigMap se reescriben con el nuevo nombre")
+	CmdConfigMap.PersistentFlags().StringVar(&configMapSpec, "spec", "", "Fichero con varios ConfigMaps y sus orígenes indexados por nombre")
+	CmdConfigMap.PersistentFlags().StringVar(&configMapFormat, "format", manifests.FormatYAML, "Formato de salida: yaml o json")
+	CmdConfigMap.PersistentFlags().StringVarP(&configMapOutput, "output", "o", "", "Fichero donde escribir el resultado en lugar de la salida estándar")
 	CmdRoot.AddCommand(CmdConfigMap)
 }
 
 var CmdConfigMap = &cobra.Command{
-	Use:   "configmap",
+	Use:   "configmap [file | key=file | key=- | directory]...",
 	Short: "Genera un ConfigMap de Kubernetes a partir de uno o varios ficheros",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return errors.New("empty args")
-		}
-		if configMapName == "" {
-			return errors.New("--name argument is required")
+		specs, err := configMapSpecs(cmd, args)
+		if err != nil {
+			return errors.Trace(err)
 		}
 
-		cnf := &cmConfig{
-			Kind:       "ConfigMap",
-			APIVersion: "v1",
-			Data:       make(map[string]string),
-			Metadata:   metadata{Name: configMapName},
-		}
-		for _, arg := range args {
-			content, err := ioutil.ReadFile(arg)
+		var objects []*manifests.Object
+		for _, spec := range specs {
+			cm, err := spec.Build()
 			if err != nil {
 				return errors.Trace(err)
 			}
-			cnf.Data[filepath.Base(arg)] = string(content)
+			for _, patch := range spec.Patch {
+				if err := patchConfigMapReferences(patch, spec.Name, cm.Metadata.Name); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			objects = append(objects, cm)
 		}
 
-		output, err := yaml.Marshal(cnf)
+		return errors.Trace(writeManifests(configMapOutput, configMapFormat, objects))
+	},
+}
+
+// configMapSpecs reads the ConfigMaps of the spec file or the only one
+// described by the flags.
+func configMapSpecs(cmd *cobra.Command, args []string) ([]*manifests.ConfigMapSpec, error) {
+	if configMapSpec != "" {
+		if len(args) > 0 {
+			return nil, errors.New("--spec cannot be combined with sources")
+		}
+		// The spec file describes each ConfigMap completely.
+		for _, name := range []string{"name", "namespace", "from-env-file", "from-literal", "label", "annotation", "hash-suffix", "patch"} {
+			if cmd.Flags().Changed(name) {
+				return nil, errors.Errorf("--spec cannot be combined with --%s, configure it in %s", name, configMapSpec)
+			}
+		}
+		specs, err := manifests.LoadSpecs(configMapSpec)
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
+		}
+		if len(specs) == 0 {
+			return nil, errors.Errorf("no configmaps in %s", configMapSpec)
 		}
+		return specs, nil
+	}
 
-		fmt.Println(string(output))
+	if configMapName == "" {
+		return nil, errors.New("--name argument is required")
+	}
+	if len(args) == 0 && len(configMapEnvFiles) == 0 && len(configMapLiterals) == 0 {
+		return nil, errors.New("empty args")
+	}
+	spec := &manifests.ConfigMapSpec{
+		Name:       configMapName,
+		Namespace:  configMapNamespace,
+		Files:      args,
+		EnvFiles:   configMapEnvFiles,
+		Literals:   configMapLiterals,
+		HashSuffix: configMapHashSuffix,
+		Patch:      configMapPatch,
+	}
+	var err error
+	if spec.Labels, err = manifests.ParseMap(configMapLabels); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if spec.Annotations, err = manifests.ParseMap(configMapAnnotations); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return []*manifests.ConfigMapSpec{spec}, nil
+}
 
+func patchConfigMapReferences(filename, name, newName string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	patched, replaced, err := manifests.PatchConfigMapReferences(content, name, newName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot patch %s", filename)
+	}
+	if replaced == 0 {
+		log.WithField("manifest", filename).Warning("No references to the ConfigMap found")
 		return nil
-	},
-}
+	}
 
-type cmConfig struct {
-	APIVersion string            `yaml:"apiVersion"`
-	Kind       string            `yaml:"kind"`
-	Metadata   metadata          `yaml:"metadata"`
-	Data       map[string]string `yaml:"data"`
+	return errors.Trace(ioutil.WriteFile(filename, patched, 0644))
 }
 
-type metadata struct {
-	Name string `yaml:"name"`
+// writeManifests writes the objects to the file or to the standard output if
+// the filename is empty.
+func writeManifests(filename, format string, objects []*manifests.Object) error {
+	if filename == "" || filename == "-" {
+		return errors.Trace(manifests.Encode(os.Stdout, format, objects))
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := manifests.Encode(f, format, objects); err != nil {
+		f.Close()
+		return errors.Trace(err)
+	}
+	return errors.Trace(f.Close())
 }
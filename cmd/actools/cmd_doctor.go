@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/doctor"
+)
+
+var doctorJSON bool
+
+func init() {
+	CmdDoctor.PersistentFlags().BoolVar(&doctorJSON, "json", false, "Imprime los resultados en formato JSON")
+	CmdRoot.AddCommand(CmdDoctor)
+}
+
+var CmdDoctor = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnostica los problemas más habituales del entorno de desarrollo.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		results := doctor.Run()
+
+		if doctorJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return errors.Trace(err)
+			}
+		} else {
+			for _, result := range results {
+				fmt.Printf("[%s] %s: %s\n", result.Status, result.Check, result.Message)
+				if result.Hint != "" {
+					fmt.Printf("       %s\n", result.Hint)
+				}
+			}
+		}
+
+		var failed int
+		for _, result := range results {
+			if result.Status == doctor.StatusFail {
+				failed++
+			}
+		}
+		if failed > 0 {
+			log.WithField("failed", failed).Error("Some checks failed")
+			return errors.Errorf("%d checks failed", failed)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/scaffold"
+)
+
+const configFilename = "actools.yml"
+
+var (
+	initForce       bool
+	initInteractive bool
+)
+
+func init() {
+	CmdInit.PersistentFlags().BoolVar(&initForce, "force", false, "Sobrescribe el fichero actools.yml si ya existe")
+	CmdInit.PersistentFlags().BoolVarP(&initInteractive, "interactive", "i", false, "Pregunta antes de añadir cada propuesta")
+	CmdRoot.AddCommand(CmdInit)
+}
+
+var CmdInit = &cobra.Command{
+	Use:   "init",
+	Short: "Genera el fichero actools.yml a partir de los ficheros del proyecto.",
+	Long: `Genera el fichero actools.yml a partir de los ficheros del proyecto.
+
+Busca go.mod, package.json, composer.json, app.yaml, ficheros .proto y
+directorios de migraciones para proponer servicios, herramientas y versiones.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := os.Stat(configFilename); err == nil && !initForce {
+			return errors.Errorf("%s already exists, use --force to overwrite it", configFilename)
+		} else if err != nil && !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+
+		detection, err := scaffold.Detect(".")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		items, notes := scaffold.Propose(detection)
+		for _, note := range notes {
+			log.Warning(note)
+		}
+
+		project := config.ProjectName()
+		if initInteractive {
+			in := bufio.NewReader(os.Stdin)
+			if answer := ask(in, fmt.Sprintf("Project name [%s]: ", project)); answer != "" {
+				project = answer
+			}
+
+			var selected []*scaffold.Item
+			for _, item := range items {
+				answer := strings.ToLower(ask(in, fmt.Sprintf("Add %s? [Y/n]: ", item)))
+				if answer == "" || answer == "y" || answer == "yes" {
+					selected = append(selected, item)
+				}
+			}
+			items = selected
+		}
+		if len(items) == 0 {
+			log.Warning("Nothing detected in the project, writing only the project name")
+		}
+
+		if err := ioutil.WriteFile(configFilename, scaffold.Render(project, items), 0644); err != nil {
+			return errors.Trace(err)
+		}
+		log.WithField("entries", len(items)).Info("actools.yml written")
+
+		return nil
+	},
+}
+
+func ask(in *bufio.Reader, question string) string {
+	fmt.Print(question)
+	answer, _ := in.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	CmdRoot.AddCommand(CmdLock)
+}
+
+var CmdLock = &cobra.Command{
+	Use:   "lock",
+	Short: "Gestiona el fichero actools.lock con los digests de las imágenes del proyecto.",
+}
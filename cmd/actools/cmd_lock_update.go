@@ -0,0 +1,60 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/containers"
+	"github.com/altipla-consulting/actools/pkg/lockfile"
+)
+
+func init() {
+	CmdLock.AddCommand(CmdLockUpdate)
+}
+
+var CmdLockUpdate = &cobra.Command{
+	Use:   "update [image...]",
+	Short: "Descarga las últimas versiones de las imágenes y anota sus digests en actools.lock.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		images := args
+		if len(images) == 0 {
+			images = containers.ProjectImages()
+		}
+		if len(images) == 0 {
+			return errors.Errorf("no images to lock, pass them as arguments: actools lock update go node")
+		}
+
+		for _, name := range images {
+			container, err := containers.FindImage(name)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			image, err := container.UnpinnedImage()
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			log.WithFields(log.Fields{
+				"image":   name,
+				"version": image.Tag(),
+			}).Info("Download image")
+
+			if err := image.Pull(); err != nil {
+				return errors.Trace(err)
+			}
+			digest, err := image.RepoDigest()
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			log.WithFields(log.Fields{
+				"image":  name,
+				"digest": digest,
+			}).Info("Image locked")
+			lockfile.Current.Set(name, image.Tag(), digest)
+		}
+
+		return errors.Trace(lockfile.Current.Save())
+	},
+}
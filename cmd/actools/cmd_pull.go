@@ -2,34 +2,149 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 	"libs.altipla.consulting/errors"
 
+	"github.com/altipla-consulting/actools/pkg/config"
 	"github.com/altipla-consulting/actools/pkg/containers"
 	"github.com/altipla-consulting/actools/pkg/docker"
 )
 
-const GoVersion = "go1.13.8"
+const pullAttempts = 4
+
+var (
+	pullAll  bool
+	pullJobs int
+)
 
 func init() {
+	CmdPull.PersistentFlags().BoolVar(&pullAll, "all", false, "Descarga todas las imágenes del catálogo y no solo las que usa el proyecto")
+	CmdPull.PersistentFlags().IntVarP(&pullJobs, "jobs", "j", 4, "Número de imágenes que se descargan a la vez")
 	CmdRoot.AddCommand(CmdPull)
 }
 
 var CmdPull = &cobra.Command{
 	Use:   "pull",
 	Short: "Descarga y actualiza forzosamente las imágenes de los contenedores de herramientas.",
+	Long: `Descarga y actualiza forzosamente las imágenes de los contenedores de herramientas.
+
+Por defecto descarga todas las imágenes que necesita el proyecto, por lo que sirve
+para preparar el equipo antes de trabajar sin conexión con --offline.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		for _, image := range containers.Images() {
-			log.WithField("image", image).Info("Download image")
+		if config.Offline() {
+			return errors.Errorf("cannot download images in offline mode")
+		}
+		if pullJobs < 1 {
+			return errors.Errorf("invalid number of jobs: %d", pullJobs)
+		}
+
+		var list []containers.Container
+		if pullAll {
+			list = containers.List()
+		} else {
+			for _, name := range containers.ProjectImages() {
+				container, err := containers.FindImage(name)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				list = append(list, container)
+			}
+		}
+		if len(list) == 0 {
+			log.Warning("The project does not reference any image in actools.yml or actools.lock. Run `actools pull --all` to download the whole catalog.")
+			return nil
+		}
+
+		var mu sync.Mutex
+		var changed []string
+		var done int
+
+		g := new(errgroup.Group)
+		sem := make(chan struct{}, pullJobs)
+		for _, container := range list {
+			container := container
 
-			image := docker.Image("eu.gcr.io", fmt.Sprintf("altipla-tools/%s", image))
-			if err := image.Pull(); err != nil {
+			// Pinned images download the exact version of actools.lock.
+			image, err := container.DockerImage()
+			if err != nil {
 				return errors.Trace(err)
 			}
+
+			g.Go(func() error {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				logger := log.WithField("image", image.String())
+				logger.Info("Download image")
+
+				// Errors are expected if the image was never downloaded before.
+				before, _ := image.RepoDigest()
+
+				if err := pullWithRetries(image); err != nil {
+					return errors.Trace(err)
+				}
+
+				after, err := image.RepoDigest()
+				if err != nil {
+					return errors.Trace(err)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				done++
+				logger.WithField("progress", fmt.Sprintf("%d/%d", done, len(list))).Info("Image downloaded")
+				if before != after {
+					changed = append(changed, container.Image)
+				}
+
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return errors.Trace(err)
+		}
+
+		sort.Strings(changed)
+		for _, name := range changed {
+			log.WithField("image", name).Info("Image updated")
 		}
+		log.WithFields(log.Fields{
+			"updated":   len(changed),
+			"unchanged": len(list) - len(changed),
+		}).Info("Pull finished")
 
 		return nil
 	},
 }
+
+func pullWithRetries(image *docker.ImageManager) error {
+	wait := 2 * time.Second
+	for attempt := 1; ; attempt++ {
+		err := image.PullQuiet()
+		if err == nil {
+			return nil
+		}
+
+		// Only network failures are transient, retrying the rest is useless.
+		permanent := errors.Is(err, docker.ErrImageNotFound) ||
+			errors.Is(err, docker.ErrDaemonUnavailable) ||
+			errors.Is(err, docker.ErrPermissionDenied)
+		if permanent || attempt == pullAttempts {
+			return errors.Trace(err)
+		}
+
+		log.WithFields(log.Fields{
+			"image":   image.String(),
+			"attempt": attempt,
+			"error":   err.Error(),
+		}).Warningf("Cannot download image, retrying in %s", wait)
+		time.Sleep(wait)
+		wait = 2 * wait
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/run"
+)
+
+const (
+	tagFromID  = "id"
+	tagFromGit = "git"
+)
+
+var (
+	pushTag     string
+	pushTagFrom string
+)
+
+func init() {
+	CmdPush.PersistentFlags().StringVar(&pushTag, "tag", "", "Etiqueta con la que se suben las imágenes")
+	CmdPush.PersistentFlags().StringVar(&pushTagFrom, "tag-from", tagFromID, "Etiqueta por defecto si no se indica --tag: id (identificador corto de la imagen) o git (git describe)")
+	CmdRoot.AddCommand(CmdPush)
+}
+
+var CmdPush = &cobra.Command{
+	Use:   "push [image]...",
+	Short: "Sube al registro las imágenes del proyecto construidas con actools build.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.Offline() {
+			return errors.Errorf("cannot push images in offline mode")
+		}
+
+		names, err := selectProjectImages(args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		tag := pushTag
+		if tag == "" {
+			switch pushTagFrom {
+			case tagFromID:
+			case tagFromGit:
+				tag, err = run.InteractiveCaptureOutput("git", "describe", "--tags", "--always", "--dirty")
+				if err != nil {
+					return errors.Wrapf(err, "cannot describe the git version")
+				}
+			default:
+				return errors.Errorf("invalid --tag-from value: %s", pushTagFrom)
+			}
+		}
+
+		for _, name := range names {
+			image := projectImage(config.Settings.Images[name])
+
+			imageTag := tag
+			if imageTag == "" {
+				imageTag, err = image.LastBuiltID()
+				if err != nil {
+					return errors.Wrapf(err, "image %s is not built", name)
+				}
+			}
+
+			if err := image.Push(imageTag); err != nil {
+				return errors.Trace(err)
+			}
+			log.WithFields(log.Fields{
+				"image": name,
+				"tag":   imageTag,
+			}).Info("Image pushed")
+		}
+
+		return nil
+	},
+}
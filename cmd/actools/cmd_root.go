@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"libs.altipla.consulting/errors"
@@ -9,10 +11,14 @@ import (
 	"github.com/altipla-consulting/actools/pkg/update"
 )
 
-var debugApp bool
+var (
+	debugApp   bool
+	offlineApp bool
+)
 
 func init() {
 	CmdRoot.PersistentFlags().BoolVarP(&debugApp, "debug", "d", false, "Activa el logging de depuración")
+	CmdRoot.PersistentFlags().BoolVar(&offlineApp, "offline", false, "No accede a la red y usa solo las imágenes descargadas. También se activa con ACTOOLS_OFFLINE=1 o true")
 }
 
 var CmdRoot = &cobra.Command{
@@ -25,11 +31,26 @@ var CmdRoot = &cobra.Command{
 			log.Debug("DEBUG log level activated")
 		}
 
+		// Export the flag to share it with the rest of packages.
+		if offlineApp {
+			if err := os.Setenv("ACTOOLS_OFFLINE", "1"); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
 		if config.Development() {
 			log.Warning("Running development version. To download a production version run: curl https://tools.altipla.consulting/install/actools | bash")
 		}
 
+		// The update commands should be able to run even if the version is outdated.
+		if cmd == CmdUpdate || cmd == CmdUpdateCheck {
+			return nil
+		}
+
 		if err := update.Check(); err != nil {
+			if errors.Is(err, update.ErrOutdated) {
+				os.Exit(2)
+			}
 			return errors.Trace(err)
 		}
 
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"libs.altipla.consulting/errors"
 
+	"github.com/altipla-consulting/actools/pkg/config"
 	"github.com/altipla-consulting/actools/pkg/containers"
 	"github.com/altipla-consulting/actools/pkg/docker"
 )
@@ -31,11 +32,33 @@ var CmdRun = &cobra.Command{
 
 func createRunEntrypoint(containerDesc containers.Container) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
+		image, err := containerDesc.DockerImage()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// Fail fast in offline mode before creating networks or containers.
+		if config.Offline() {
+			if err := docker.RequireLocal(image); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err := image.WarnMismatch(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := image.WarnEmulated(); err != nil {
+			return errors.Trace(err)
+		}
+
 		options := []docker.ContainerOption{
-			docker.WithImage(docker.Image(containers.Repo, containerDesc.Image)),
+			docker.WithImage(image),
 			docker.WithDefaultNetwork(),
 		}
 		options = append(options, containerDesc.Options...)
+		secretOptions, err := secretsOptions(containerDesc)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		options = append(options, secretOptions...)
 
 		container, err := docker.Container(fmt.Sprintf("run-%s", containerDesc.Image), options...)
 		if err != nil {
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/manifests"
+	"github.com/altipla-consulting/actools/pkg/secrets"
+)
+
+var (
+	secretName        string
+	secretNamespace   string
+	secretType        string
+	secretEnvFiles    []string
+	secretLiterals    []string
+	secretFromSecrets []string
+	secretLabels      []string
+	secretAnnotations []string
+)
+
+func init() {
+	CmdSecret.PersistentFlags().StringVar(&secretName, "name", "", "Nombre del Secret que vamos a generar")
+	CmdSecret.PersistentFlags().StringVar(&secretNamespace, "namespace", "", "Namespace del Secret")
+	CmdSecret.PersistentFlags().StringVar(&secretType, "type", manifests.SecretTypeOpaque, "Tipo del Secret: Opaque, kubernetes.io/tls o kubernetes.io/dockerconfigjson")
+	CmdSecret.PersistentFlags().StringArrayVar(&secretEnvFiles, "from-env-file", nil, "Fichero con líneas KEY=VALUE que se añaden como claves")
+	CmdSecret.PersistentFlags().StringArrayVar(&secretLiterals, "from-literal", nil, "Clave con su valor en formato KEY=VALUE")
+	CmdSecret.PersistentFlags().StringArrayVar(&secretFromSecrets, "from-secrets", nil, "Clave de los secretos cifrados del proyecto que se añade con el mismo nombre")
+	CmdSecret.PersistentFlags().StringArrayVar(&secretLabels, "label", nil, "Etiqueta del Secret en formato KEY=VALUE")
+	CmdSecret.PersistentFlags().StringArrayVar(&secretAnnotations, "annotation", nil, "Anotación del Secret en formato KEY=VALUE")
+	CmdRoot.AddCommand(CmdSecret)
+}
+
+var CmdSecret = &cobra.Command{
+	Use:   "secret [file | key=file | directory]...",
+	Short: "Genera un Secret de Kubernetes a partir de uno o varios ficheros",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if secretName == "" {
+			return errors.New("--name argument is required")
+		}
+
+		sources := manifests.Sources{
+			Files:    args,
+			EnvFiles: secretEnvFiles,
+			Literals: secretLiterals,
+		}
+		if len(secretFromSecrets) > 0 {
+			encrypted, err := secrets.Env()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, key := range secretFromSecrets {
+				value, ok := encrypted[key]
+				if !ok {
+					return errors.Errorf("key %q not found in %s", key, secrets.Filename)
+				}
+				sources.Literals = append(sources.Literals, key+"="+value)
+			}
+		}
+		if sources.Empty() {
+			return errors.New("empty args")
+		}
+		values, err := sources.Load()
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		metadata := manifests.Metadata{
+			Name:      secretName,
+			Namespace: secretNamespace,
+		}
+		if metadata.Labels, err = manifests.ParseMap(secretLabels); err != nil {
+			return errors.Trace(err)
+		}
+		if metadata.Annotations, err = manifests.ParseMap(secretAnnotations); err != nil {
+			return errors.Trace(err)
+		}
+
+		secret, err := manifests.NewSecret(metadata, secretType, values)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(writeManifests("", manifests.FormatYAML, []*manifests.Object{secret}))
+	},
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/containers"
+	"github.com/altipla-consulting/actools/pkg/docker"
+	"github.com/altipla-consulting/actools/pkg/secrets"
+)
+
+func init() {
+	CmdRoot.AddCommand(CmdSecrets)
+}
+
+var CmdSecrets = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the encrypted secrets of the project.",
+	Long: `Manage the encrypted secrets of the project.
+
+The containers listed in the secrets key of actools.yml receive the decrypted
+secrets as environment variables. The rest of tools run without decrypting them:
+
+  secrets:
+  - go
+  - node
+`,
+}
+
+// secretsOptions injects the decrypted secrets of the project as environment
+// variables of the container if the project declares it needs them. Users without
+// access run the tools without them.
+func secretsOptions(containerDesc containers.Container) ([]docker.ContainerOption, error) {
+	if !receivesSecrets(containerDesc) {
+		return nil, nil
+	}
+
+	values, err := secrets.Env()
+	if err != nil {
+		if errors.Is(err, secrets.ErrNoIdentity) || errors.Is(err, secrets.ErrNotRecipient) || errors.Is(err, secrets.ErrPassphraseRequired) {
+			log.WithField("reason", err.Error()).Warning("Cannot decrypt the project secrets, running without them")
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+
+	var options []docker.ContainerOption
+	for name, value := range values {
+		options = append(options, docker.WithSecretEnv(name, value))
+	}
+	return options, nil
+}
+
+func receivesSecrets(containerDesc containers.Container) bool {
+	for _, image := range config.Settings.Secrets {
+		if image == containerDesc.Image {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/secrets"
+)
+
+func init() {
+	CmdSecrets.AddCommand(CmdSecretsAddRecipient)
+}
+
+var CmdSecretsAddRecipient = &cobra.Command{
+	Use:   "add-recipient <public key | file.pub>",
+	Short: "Give access to the secrets to a new SSH public key.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		line := args[0]
+		if content, err := ioutil.ReadFile(args[0]); err == nil {
+			line = string(content)
+		} else if !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+		key, comment, err := secrets.ParseRecipient(line)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		file, err := secrets.Load()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		identity, err := secrets.LoadIdentity(file)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := file.AddRecipient(identity, key, comment); err != nil {
+			return errors.Trace(err)
+		}
+		if err := file.Save(); err != nil {
+			return errors.Trace(err)
+		}
+
+		log.WithFields(log.Fields{
+			"fingerprint": ssh.FingerprintSHA256(key),
+			"comment":     comment,
+		}).Info("Recipient added")
+
+		return nil
+	},
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/secrets"
+)
+
+func init() {
+	CmdSecrets.AddCommand(CmdSecretsEdit)
+}
+
+var CmdSecretsEdit = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the decrypted secrets in the editor and encrypt them again when finished.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, identity, plaintext, err := openSecrets()
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// Prefer a directory in memory for the only copy in clear text.
+		dir := ""
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			dir = "/dev/shm"
+		}
+		tmp, err := ioutil.TempFile(dir, "actools-secrets-*.env")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(plaintext); err != nil {
+			tmp.Close()
+			return errors.Trace(err)
+		}
+		if err := tmp.Close(); err != nil {
+			return errors.Trace(err)
+		}
+
+		editor := os.Getenv("EDITOR")
+		if editor == "" {
+			editor = "vi"
+		}
+		edit := exec.Command(editor, tmp.Name())
+		edit.Stdin = os.Stdin
+		edit.Stdout = os.Stdout
+		edit.Stderr = os.Stderr
+		if err := edit.Run(); err != nil {
+			return errors.Wrapf(err, "editor failed")
+		}
+
+		edited, err := ioutil.ReadFile(tmp.Name())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := file.Seal(identity, edited); err != nil {
+			return errors.Trace(err)
+		}
+		if err := file.Save(); err != nil {
+			return errors.Trace(err)
+		}
+
+		log.WithField("file", secrets.Filename).Info("Secrets saved")
+
+		return nil
+	},
+}
+
+// openSecrets decrypts the secrets of the project or prepares new ones with
+// the current user as the only recipient.
+func openSecrets() (*secrets.File, *secrets.Identity, []byte, error) {
+	exists, err := secrets.Exists()
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	if !exists {
+		identity, err := secrets.LoadIdentity(nil)
+		if err != nil {
+			return nil, nil, nil, errors.Trace(err)
+		}
+		file, err := secrets.New(identity)
+		if err != nil {
+			return nil, nil, nil, errors.Trace(err)
+		}
+		return file, identity, []byte("# KEY=VALUE lines injected in the tools and services.\n"), nil
+	}
+
+	file, err := secrets.Load()
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	identity, err := secrets.LoadIdentity(file)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	plaintext, err := file.Plaintext(identity)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	return file, identity, plaintext, nil
+}
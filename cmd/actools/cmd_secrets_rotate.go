@@ -0,0 +1,49 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/secrets"
+)
+
+var secretsRotateRemove []string
+
+func init() {
+	CmdSecretsRotate.PersistentFlags().StringArrayVar(&secretsRotateRemove, "remove", nil, "Huella SHA256 del destinatario que pierde el acceso")
+	CmdSecrets.AddCommand(CmdSecretsRotate)
+}
+
+var CmdSecretsRotate = &cobra.Command{
+	Use:   "rotate",
+	Short: "Encrypt the secrets with a new key, optionally removing recipients.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := secrets.Load()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		identity, err := secrets.LoadIdentity(file)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := file.Rotate(identity, secretsRotateRemove); err != nil {
+			return errors.Trace(err)
+		}
+		if err := file.Save(); err != nil {
+			return errors.Trace(err)
+		}
+
+		for _, recipient := range file.Recipients {
+			log.WithFields(log.Fields{
+				"fingerprint": recipient.Fingerprint(),
+				"comment":     recipient.Comment(),
+			}).Info("Recipient")
+		}
+		if len(secretsRotateRemove) > 0 {
+			log.Warning("Removed recipients could have a copy of the previous values. Change the credentials too.")
+		}
+
+		return nil
+	},
+}
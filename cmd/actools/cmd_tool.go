@@ -38,8 +38,25 @@ func init() {
 
 func createToolEntrypoint(containerDesc containers.Container, tool, workdir string) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
+		image, err := containerDesc.DockerImage()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// Fail fast in offline mode before creating networks or containers.
+		if config.Offline() {
+			if err := docker.RequireLocal(image); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err := image.WarnMismatch(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := image.WarnEmulated(); err != nil {
+			return errors.Trace(err)
+		}
+
 		options := []docker.ContainerOption{
-			docker.WithImage(docker.Image(containers.Repo, containerDesc.Image)),
+			docker.WithImage(image),
 			docker.WithDefaultNetwork(),
 			docker.WithEnv("PROJECT", config.Settings.Project),
 
@@ -47,6 +64,11 @@ func createToolEntrypoint(containerDesc containers.Container, tool, workdir stri
 			docker.WithEnv("BUILD_NUMBER", os.Getenv("BUILD_NUMBER")),
 		}
 		options = append(options, containerDesc.Options...)
+		secretOptions, err := secretsOptions(containerDesc)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		options = append(options, secretOptions...)
 		if workdir != "" {
 			options = append(options, docker.WithWorkdir(fmt.Sprintf("/workspace/%s", workdir)))
 		}
@@ -3,21 +3,84 @@ package main
 import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/update"
+)
+
+var (
+	updateRollback bool
+	updateForce    bool
+	updateChannel  string
 )
 
 func init() {
+	CmdUpdate.PersistentFlags().StringVar(&updateChannel, "channel", "", "Cambia el canal de versiones (stable, beta) e instala su última versión")
+	CmdUpdate.PersistentFlags().BoolVar(&updateRollback, "rollback", false, "Restaura la versión que había instalada antes de la última actualización")
+	CmdUpdate.PersistentFlags().BoolVar(&updateForce, "force", false, "Instala la última versión aunque ya esté actualizada")
+	CmdUpdate.AddCommand(CmdUpdateCheck)
 	CmdRoot.AddCommand(CmdUpdate)
 }
 
+var CmdUpdateCheck = &cobra.Command{
+	Use:    "check",
+	Short:  "Comprueba en segundo plano si hay nuevas versiones de la herramienta.",
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.Trace(update.Refresh())
+	},
+}
+
 var CmdUpdate = &cobra.Command{
 	Use:   "update",
-	Short: "Imprime el comando de actualización de la herramienta.",
+	Short: "Actualiza la herramienta a la última versión publicada.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		log.Info()
-		log.Info("Run the following command to install the latest version:")
-		log.Info()
-		log.Info("\tcurl https://tools.altipla.consulting/install/actools | bash")
-		log.Info()
+		if updateRollback {
+			if err := update.Rollback(); err != nil {
+				return errors.Trace(err)
+			}
+			log.Info("Previous version restored")
+			return nil
+		}
+
+		// Switching channels installs the latest build of the new one even if it
+		// has a lower version than the current one.
+		force := updateForce
+		if updateChannel != "" && updateChannel != config.Channel() {
+			if !config.ValidChannel(updateChannel) {
+				return errors.Errorf("unknown channel %q, use %s or %s", updateChannel, config.ChannelStable, config.ChannelBeta)
+			}
+			config.Global.Channel = updateChannel
+			if err := config.SaveGlobal(); err != nil {
+				return errors.Trace(err)
+			}
+			log.WithField("channel", updateChannel).Info("Release channel changed")
+			force = true
+		}
+
+		manifest, err := update.FetchManifest(config.Channel())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		outdated, err := update.IsOutdated(manifest)
+		if err != nil && !config.Development() {
+			return errors.Trace(err)
+		}
+		if !outdated && !config.Development() && !force {
+			log.WithField("version", config.Version).Info("actools is already updated")
+			return nil
+		}
+
+		log.WithFields(log.Fields{
+			"current": config.Version,
+			"latest":  manifest.Latest,
+			"channel": config.Channel(),
+		}).Info("Install new version")
+		if err := update.Install(config.Channel(), manifest.Latest); err != nil {
+			return errors.Trace(err)
+		}
+		log.Info("actools updated successfully. Run `actools update --rollback` to restore the previous version if needed.")
 
 		return nil
 	},
@@ -1,22 +1,126 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"libs.altipla.consulting/errors"
 
 	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/containers"
+)
+
+var (
+	versionJSON   bool
+	versionImages bool
 )
 
 func init() {
+	CmdVersion.PersistentFlags().BoolVar(&versionJSON, "json", false, "Imprime la información en formato JSON")
+	CmdVersion.PersistentFlags().BoolVar(&versionImages, "images", false, "Incluye las imágenes del catálogo descargadas en local")
 	CmdRoot.AddCommand(CmdVersion)
 }
 
+type versionReport struct {
+	*config.BuildInfo
+	Images []*imageReport `json:"images,omitempty"`
+}
+
+type imageReport struct {
+	Image    string     `json:"image"`
+	Tag      string     `json:"tag"`
+	Digest   string     `json:"digest,omitempty"`
+	Platform string     `json:"platform,omitempty"`
+	Created  *time.Time `json:"created,omitempty"`
+}
+
 var CmdVersion = &cobra.Command{
 	Use:   "version",
 	Short: "Imprime la versión de la herramienta.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println(config.Version)
+		report := &versionReport{BuildInfo: config.Build()}
+		if versionImages {
+			for _, container := range containers.List() {
+				image, err := container.DockerImage()
+				if err != nil {
+					return errors.Trace(err)
+				}
+				ir := &imageReport{
+					Image: container.Image,
+					Tag:   image.Tag(),
+				}
+				report.Images = append(report.Images, ir)
+
+				exists, err := image.Exists()
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if !exists {
+					continue
+				}
+				info, err := image.Inspect()
+				if err != nil {
+					return errors.Trace(err)
+				}
+				ir.Created = &info.Created
+				ir.Platform = info.Platform()
+
+				// Images built locally do not have a digest from the registry.
+				if digest, err := image.RepoDigest(); err == nil {
+					ir.Digest = digest
+				}
+			}
+		}
+
+		if versionJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return errors.Trace(enc.Encode(report))
+		}
+
+		fmt.Println(report.Version)
+		fmt.Println("Channel:", report.Channel)
+		if report.Commit != "" {
+			commit := report.Commit
+			if report.Dirty {
+				commit += " (dirty)"
+			}
+			fmt.Println("Commit:", commit)
+		}
+		if report.CommitDate != "" {
+			fmt.Println("Commit date:", report.CommitDate)
+		}
+		if report.Date != "" {
+			fmt.Println("Build date:", report.Date)
+		}
+		fmt.Println("Go version:", report.GoVersion)
+		fmt.Println("Platform:", report.Platform)
+
+		if versionImages {
+			fmt.Println()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "IMAGE\tTAG\tDIGEST\tPLATFORM\tCREATED")
+			for _, ir := range report.Images {
+				digest, platform, created := "-", "-", "not downloaded"
+				if ir.Digest != "" {
+					digest = ir.Digest
+				}
+				if ir.Platform != "" {
+					platform = ir.Platform
+				}
+				if ir.Created != nil {
+					created = ir.Created.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ir.Image, ir.Tag, digest, platform, created)
+			}
+			if err := w.Flush(); err != nil {
+				return errors.Trace(err)
+			}
+		}
 
 		return nil
 	},
@@ -8,16 +8,47 @@ GOOGLE_PROJECT=altipla-tools
 
 configure-google-cloud
 
-run "sed -i 's/dev/$(build-tag)/g' pkg/config/version.go"
-run 'actools go build -o actools ./cmd/actools'
-run "gsutil -h 'Cache-Control: no-cache' cp actools gs://tools.altipla.consulting/bin/actools"
-
-run "echo $(build-tag) > version"
-run "gsutil -h 'Cache-Control: no-cache' cp version gs://tools.altipla.consulting/version-manifest/actools"
-
-for FILE in containers/*/Dockerfile; do
-  APP=$(basename $(dirname $FILE))
-  docker-build-autotag eu.gcr.io/$GOOGLE_PROJECT/$APP containers/$APP/Dockerfile containers/$APP
-done
+# CHANNEL selects who receives the build: stable for everyone or beta to dogfood it.
+CHANNEL=${CHANNEL:-stable}
+
+# The version is injected with the linker to keep the checkout clean and report it as not modified.
+LDFLAGS="-X github.com/altipla-consulting/actools/pkg/config.Version=$(build-tag)"
+LDFLAGS="$LDFLAGS -X github.com/altipla-consulting/actools/pkg/config.commit=$(git rev-parse HEAD)"
+LDFLAGS="$LDFLAGS -X github.com/altipla-consulting/actools/pkg/config.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+run "actools go build -ldflags '$LDFLAGS' -o actools-$CHANNEL ./cmd/actools"
+run "sha256sum actools-$CHANNEL > actools-$CHANNEL.sha256"
+# The release statement signs the version together with the binary, so an older
+# build cannot be served in place of the latest one. The plain signature of the
+# binary is still published for the versions released before the statement.
+run "echo \"actools-$CHANNEL $(build-tag) $(sha256sum actools-$CHANNEL | cut -d' ' -f1)\" > actools-$CHANNEL.release"
+run "openssl pkeyutl -sign -rawin -inkey $ACTOOLS_SIGNING_KEY -in actools-$CHANNEL.release -out actools-$CHANNEL.release.sig"
+run "openssl pkeyutl -sign -rawin -inkey $ACTOOLS_SIGNING_KEY -in actools-$CHANNEL -out actools-$CHANNEL.sig"
+run "gsutil -h 'Cache-Control: no-cache' cp actools-$CHANNEL actools-$CHANNEL.sha256 actools-$CHANNEL.sig actools-$CHANNEL.release.sig gs://tools.altipla.consulting/bin/"
+
+# MINIMUM_VERSION forces older versions to update before running any command.
+run "echo '{\"latest\": \"$(build-tag)\", \"minimum\": \"${MINIMUM_VERSION:-}\"}' > version"
+run "gsutil -h 'Cache-Control: no-cache' cp version gs://tools.altipla.consulting/version-manifest/actools-$CHANNEL"
+
+# The install script and older versions of actools read the stable build from the original paths.
+if [[ "$CHANNEL" == "stable" ]]; then
+  run "echo $(build-tag) > version-legacy"
+  run "gsutil -h 'Cache-Control: no-cache' cp actools-$CHANNEL gs://tools.altipla.consulting/bin/actools"
+  run "gsutil -h 'Cache-Control: no-cache' cp version-legacy gs://tools.altipla.consulting/version-manifest/actools"
+fi
+
+# The catalog in pkg/containers lists the images, their versions and platforms.
+# Multi-platform builds need the emulators of the foreign architectures and a
+# buildx builder able to produce them. Each image is pushed when built. Only the
+# stable channel publishes them, beta builds would replace the images of everyone.
+# Every tag is published too with the immutable build tag to pin the release.
+# Unchanged tags are skipped without --force. The hash of each tag includes the
+# registry digest of its external bases, so images on floating upstream tags like
+# node:18 or google/cloud-sdk:latest are still rebuilt with their security fixes.
+if [[ "$CHANNEL" == "stable" ]]; then
+  run "docker run --privileged --rm tonistiigi/binfmt --install arm64"
+  run "docker buildx inspect actools-catalog >/dev/null 2>&1 || docker buildx create --name actools-catalog"
+  run "docker buildx use actools-catalog"
+  run "./actools-$CHANNEL catalog build --multi-arch --release $(build-tag)"
+fi
 
 git-tag
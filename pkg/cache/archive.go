@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+// sharedModulesPrefix separates inside the tarballs the files of the shared
+// modules cache from the ones of the project.
+const sharedModulesPrefix = "@shared/go-mod/"
+
+// Export writes a compressed tarball with the whole cache of the project and
+// the shared Go modules.
+func (project *Project) Export(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := exportDir(tw, project.Dir, ""); err != nil {
+		return errors.Trace(err)
+	}
+	if err := exportDir(tw, SharedModulesDir(), sharedModulesPrefix); err != nil {
+		return errors.Trace(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(gz.Close())
+}
+
+func exportDir(tw *tar.Writer, dir, prefix string) error {
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if rel == "." || rel == markerFilename {
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		header.Name = prefix + filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Trace(err)
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return errors.Trace(err)
+	})
+}
+
+// Import extracts a tarball generated by Export over the cache of the project.
+// Existing files are replaced with the content of the tarball.
+func (project *Project) Import(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		base, name := project.Dir, header.Name
+		if strings.HasPrefix(name, sharedModulesPrefix) {
+			base, name = SharedModulesDir(), strings.TrimPrefix(name, sharedModulesPrefix)
+		}
+		dest, err := safePath(base, name)
+		if err != nil {
+			return errors.Wrapf(err, "invalid path in the cache archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if info, err := os.Lstat(dest); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return errors.Errorf("invalid path in the cache archive: %s: cannot replace the symlink %s", header.Name, dest)
+			}
+
+			// Keep directories writable to extract their content. The Go tools do
+			// not depend on the read-only permissions of the modules cache.
+			if err := os.MkdirAll(dest, os.FileMode(header.Mode).Perm()|0700); err != nil {
+				return errors.Trace(err)
+			}
+
+		case tar.TypeReg:
+			if err := extractFile(dest, header, tr); err != nil {
+				return errors.Trace(err)
+			}
+
+		case tar.TypeSymlink:
+			// Symlinks can only point to other files of the same cache.
+			if filepath.IsAbs(header.Linkname) || !within(base, filepath.Join(filepath.Dir(dest), header.Linkname)) {
+				return errors.Errorf("invalid symlink in the cache archive: %s -> %s", header.Name, header.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(dest), 0777); err != nil {
+				return errors.Trace(err)
+			}
+			if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+				return errors.Trace(err)
+			}
+			if err := os.Symlink(header.Linkname, dest); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// safePath returns the destination of an entry of the archive. It fails if the
+// entry escapes the base directory, either with its name or writing through a
+// symlink of the cache.
+func safePath(base, name string) (string, error) {
+	dest := filepath.Join(base, filepath.FromSlash(name))
+	if !within(base, dest) {
+		return "", errors.Errorf("outside of %s", base)
+	}
+
+	rel, err := filepath.Rel(base, filepath.Dir(dest))
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if rel == "." {
+		return dest, nil
+	}
+	parent := base
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		parent = filepath.Join(parent, part)
+		info, err := os.Lstat(parent)
+		if err != nil {
+			if os.IsNotExist(err) {
+				break
+			}
+			return "", errors.Trace(err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", errors.Errorf("cannot write through the symlink %s", parent)
+		}
+	}
+
+	return dest, nil
+}
+
+// within returns true if the path is inside the base directory.
+func within(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func extractFile(dest string, header *tar.Header, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0777); err != nil {
+		return errors.Trace(err)
+	}
+
+	// The Go modules cache leaves read-only directories behind.
+	parent, err := os.Stat(filepath.Dir(dest))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if parent.Mode().Perm()&0200 == 0 {
+		if err := os.Chmod(filepath.Dir(dest), parent.Mode().Perm()|0700); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return errors.Trace(err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(os.Chtimes(dest, header.ModTime, header.ModTime))
+}
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	name     string
+	typeflag byte
+	content  string
+	linkname string
+}
+
+func archive(t *testing.T, entries ...entry) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     0644,
+			Size:     int64(len(e.content)),
+			Linkname: e.linkname,
+		}
+		if e.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if e.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if e.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
+
+func testProject(t *testing.T) *Project {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return &Project{Name: "test", Dir: filepath.Join(t.TempDir(), "cache")}
+}
+
+func TestExportImport(t *testing.T) {
+	src := testProject(t)
+	if err := os.MkdirAll(filepath.Join(src.Dir, "node", "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src.Dir, "node", "lib", "index.js"), []byte("foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("lib/index.js", filepath.Join(src.Dir, "node", "main.js")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := src.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := &Project{Name: "test", Dir: filepath.Join(t.TempDir(), "cache")}
+	if err := dest.Import(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dest.Dir, "node", "main.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "foo" {
+		t.Errorf("content = %q, want %q", content, "foo")
+	}
+	link, err := os.Readlink(filepath.Join(dest.Dir, "node", "main.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "lib/index.js" {
+		t.Errorf("link = %q, want %q", link, "lib/index.js")
+	}
+}
+
+func TestImportRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{
+			name:    "parent path",
+			entries: []entry{{name: "../evil", typeflag: tar.TypeReg, content: "evil"}},
+		},
+		{
+			name:    "absolute symlink",
+			entries: []entry{{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+		},
+		{
+			name:    "relative symlink outside",
+			entries: []entry{{name: "dir/link", typeflag: tar.TypeSymlink, linkname: "../../evil"}},
+		},
+		{
+			name: "write through extracted symlink",
+			entries: []entry{
+				{name: "dir", typeflag: tar.TypeDir},
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "dir"},
+				{name: "link/file", typeflag: tar.TypeReg, content: "evil"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			project := testProject(t)
+			if err := os.MkdirAll(project.Dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := project.Import(archive(t, test.entries...)); err == nil {
+				t.Fatal("expected error importing the archive")
+			}
+
+			if _, err := os.Lstat(filepath.Join(filepath.Dir(project.Dir), "evil")); !os.IsNotExist(err) {
+				t.Errorf("file written outside of the cache: %v", err)
+			}
+		})
+	}
+}
+
+func TestImportDoesNotFollowExistingSymlinks(t *testing.T) {
+	project := testProject(t)
+	outside := t.TempDir()
+	if err := os.MkdirAll(project.Dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(project.Dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []entry{
+		{name: "link/file", typeflag: tar.TypeReg, content: "evil"},
+		{name: "link/sub/file", typeflag: tar.TypeReg, content: "evil"},
+		{name: "link", typeflag: tar.TypeDir},
+		{name: "link/sub", typeflag: tar.TypeDir},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := project.Import(archive(t, test)); err == nil {
+				t.Fatal("expected error importing the archive")
+			}
+
+			files, err := ioutil.ReadDir(outside)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) > 0 {
+				t.Errorf("files written through the symlink: %v", files)
+			}
+		})
+	}
+}
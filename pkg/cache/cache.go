@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+)
+
+// markerFilename stores inside each cache the directory of the project that
+// uses it, to detect caches of projects that no longer exist.
+const markerFilename = ".project"
+
+type Project struct {
+	Name string
+	Dir  string
+
+	// Source is the directory of the project or empty if unknown.
+	Source string
+
+	// LastUsed is the last time a tool used the cache.
+	LastUsed time.Time
+}
+
+// Orphan returns true if the directory of the project does not exist anymore.
+func (project *Project) Orphan() bool {
+	if project.Source == "" {
+		return true
+	}
+	_, err := os.Stat(project.Source)
+	return os.IsNotExist(err)
+}
+
+// MarkProject records in the cache of the current project that it is being used.
+func MarkProject() error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := migrateLegacy(wd); err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(config.ProjectCacheDir(), 0777); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(ioutil.WriteFile(filepath.Join(config.ProjectCacheDir(), markerFilename), []byte(wd), 0644))
+}
+
+// migrateLegacy renames the cache of older versions of actools that were named
+// after the working directory. Caches marked by another checkout with the same
+// name are left untouched. Older versions did not mark the caches though, so an
+// unmarked cache moves to the first checkout that runs a tool with the same name.
+// The rest of checkouts start with an empty cache that the tools fill again.
+func migrateLegacy(wd string) error {
+	legacy := config.LegacyProjectCacheDir()
+	if _, err := os.Stat(config.ProjectCacheDir()); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(legacy, markerFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	if err == nil && strings.TrimSpace(string(content)) != wd {
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"from": legacy,
+		"to":   config.ProjectCacheDir(),
+	}).Info("Migrate the cache of the project")
+
+	// Another container of the project could be migrating it at the same time.
+	if err := os.Rename(legacy, config.ProjectCacheDir()); err != nil && !os.IsExist(err) && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+
+	return nil
+}
+
+func root() string {
+	return filepath.Join(config.Home(), ".actools")
+}
+
+// Projects lists all the caches of the user sorted by name.
+func Projects() ([]*Project, error) {
+	entries, err := ioutil.ReadDir(root())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+
+	var projects []*Project
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "cache-") {
+			continue
+		}
+
+		project := &Project{
+			Name:     strings.TrimPrefix(entry.Name(), "cache-"),
+			Dir:      filepath.Join(root(), entry.Name()),
+			LastUsed: entry.ModTime(),
+		}
+		marker := filepath.Join(project.Dir, markerFilename)
+		if info, err := os.Stat(marker); err == nil {
+			content, err := ioutil.ReadFile(marker)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			project.Source = strings.TrimSpace(string(content))
+			project.LastUsed = info.ModTime()
+		}
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+
+	return projects, nil
+}
+
+// CurrentProject returns the cache of the project in the working directory.
+func CurrentProject() (*Project, error) {
+	projects, err := Projects()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, project := range projects {
+		if project.Dir == config.ProjectCacheDir() {
+			return project, nil
+		}
+	}
+
+	return &Project{
+		Name: config.ProjectID(),
+		Dir:  config.ProjectCacheDir(),
+	}, nil
+}
+
+// Size returns the total size of the files inside a directory.
+func Size(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, errors.Trace(err)
+}
+
+// Subdirs lists the directories directly inside the cache of a project.
+func (project *Project) Subdirs() ([]string, error) {
+	entries, err := ioutil.ReadDir(project.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+
+	var subdirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			subdirs = append(subdirs, entry.Name())
+		}
+	}
+	return subdirs, nil
+}
+
+// Remove deletes the whole cache of the project.
+func (project *Project) Remove() error {
+	return errors.Trace(removeAll(project.Dir))
+}
+
+// removeAll deletes a directory even if it contains read-only directories like
+// the ones of the Go modules cache.
+func removeAll(dir string) error {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		if info.IsDir() && info.Mode().Perm()&0200 == 0 {
+			return errors.Trace(os.Chmod(path, info.Mode().Perm()|0700))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(os.RemoveAll(dir))
+}
+
+// ParseAge reads durations accepting days and weeks, like 30d or 2w, in addition
+// to the standard Go durations.
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n < 0 {
+				return 0, errors.Errorf("invalid duration: %s", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Errorf("invalid duration: %s", s)
+	}
+	return d, nil
+}
+
+// FormatSize prints a size in a human readable unit.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return strconv.FormatInt(size, 10) + " B"
+	}
+
+	value := float64(size) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit {
+			return strconv.FormatFloat(value, 'f', 1, 64) + " " + suffix
+		}
+		value /= unit
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + " TiB"
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"libs.altipla.consulting/errors"
+)
+
+// SharedModulesDir returns the Go modules download cache shared by all the
+// projects. It is content-addressed, so sharing it is always safe, and the go
+// command locks it to allow multiple containers downloading at the same time.
+func SharedModulesDir() string {
+	return filepath.Join(root(), "shared", "go-mod")
+}
+
+// MigrateModules moves the modules of a legacy per-project /go/pkg directory to
+// the shared cache. If the shared cache exists the legacy one is removed instead,
+// the modules will be downloaded again if needed.
+func MigrateModules(legacy string) error {
+	if _, err := os.Stat(legacy); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+
+	shared := SharedModulesDir()
+	if err := os.MkdirAll(filepath.Dir(shared), 0777); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := os.Stat(shared); err != nil {
+		if !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+
+		// Another container could win the race to migrate its own modules. The
+		// rename is atomic and ours will be removed below.
+		log.WithField("path", legacy).Info("Move the Go modules of the project to the shared cache")
+		if err := os.Rename(filepath.Join(legacy, "mod"), shared); err != nil && !os.IsNotExist(err) {
+			log.WithField("error", err.Error()).Debug("Cannot move the legacy Go modules")
+		}
+	}
+
+	log.WithField("path", legacy).Info("Remove the legacy Go modules cache of the project, modules are shared now")
+	return errors.Trace(removeAll(legacy))
+}
+
+// RemoveShared deletes the caches shared by all the projects.
+func RemoveShared() error {
+	return errors.Trace(removeAll(filepath.Join(root(), "shared")))
+}
+
+// UsesGo returns true if the cache contains a Go build cache in the root or in
+// the directory of any version.
+func (project *Project) UsesGo() (bool, error) {
+	matches, err := filepath.Glob(filepath.Join(project.Dir, "cache", "go-build"))
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if len(matches) > 0 {
+		return true, nil
+	}
+
+	matches, err = filepath.Glob(filepath.Join(project.Dir, "*", "cache", "go-build"))
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(matches) > 0, nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Channel    string `json:"channel"`
+	Commit     string `json:"commit,omitempty"`
+	CommitDate string `json:"commitDate,omitempty"`
+	Date       string `json:"date,omitempty"`
+	Dirty      bool   `json:"dirty"`
+	GoVersion  string `json:"goVersion"`
+	Platform   string `json:"platform"`
+}
+
+// Build returns the metadata embedded when the binary was compiled. The values
+// injected by the release pipeline take precedence over the ones of the Go toolchain.
+func Build() *BuildInfo {
+	build := &BuildInfo{
+		Version:   Version,
+		Channel:   Channel(),
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	build.GoVersion = info.GoVersion
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if build.Commit == "" {
+				build.Commit = setting.Value
+			}
+		case "vcs.time":
+			build.CommitDate = setting.Value
+		case "vcs.modified":
+			build.Dirty = setting.Value == "true"
+		}
+	}
+
+	return build
+}
@@ -28,8 +28,23 @@ func init() {
 type Config struct {
 	Project string `yaml:"project"`
 
+	// Registry and Mirrors override the repositories of the catalog images
+	// configured globally for the user.
+	Registry string   `yaml:"registry"`
+	Mirrors  []string `yaml:"mirrors"`
+
+	// Versions selects the tag of each catalog container the project runs.
+	Versions map[string]string `yaml:"versions"`
+
 	Services map[string]*Service `yaml:"services"`
 	Tools    map[string]*Tool    `yaml:"tools"`
+
+	// Secrets lists the catalog containers that receive the decrypted secrets of
+	// the project as environment variables. The rest run without decrypting them.
+	Secrets []string `yaml:"secrets"`
+
+	// Images are the images of the project built with `actools build`.
+	Images map[string]*Image `yaml:"images"`
 }
 
 func (cnf *Config) IsService(name string) bool {
@@ -59,3 +74,16 @@ type Tool struct {
 	Volumes   []string `yaml:"volumes"`
 	Args      []string `yaml:"args"`
 }
+
+type Image struct {
+	// Name is the full repository of the image without tag.
+	Name string `yaml:"name"`
+
+	// Context defaults to the root of the project and Dockerfile to the one
+	// inside the context.
+	Context    string `yaml:"context"`
+	Dockerfile string `yaml:"dockerfile"`
+
+	Args   map[string]string `yaml:"args"`
+	Target string            `yaml:"target"`
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"runtime"
+	"strings"
 )
 
 func Jenkins() bool {
@@ -21,6 +22,16 @@ func Home() string {
 	return os.Getenv("HOME")
 }
 
+// Offline returns true if actools should never access the network. Any of the
+// usual truthy values enables it: 1, true, yes or on.
+func Offline() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("ACTOOLS_OFFLINE"))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
 func Development() bool {
 	return Version == "dev"
 }
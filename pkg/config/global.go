@@ -0,0 +1,88 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+)
+
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// Global contains the settings of the user shared by every project.
+var Global = new(GlobalConfig)
+
+func init() {
+	content, err := ioutil.ReadFile(GlobalFilename())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return
+		}
+
+		log.Fatal(err)
+	}
+
+	if err := yaml.Unmarshal(content, &Global); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func GlobalFilename() string {
+	return filepath.Join(Home(), ".actools", "config.yml")
+}
+
+type GlobalConfig struct {
+	Registry string   `yaml:"registry,omitempty"`
+	Mirrors  []string `yaml:"mirrors,omitempty"`
+
+	// Channel of the releases of actools the user receives.
+	Channel string `yaml:"channel,omitempty"`
+}
+
+func SaveGlobal() error {
+	content, err := yaml.Marshal(Global)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(GlobalFilename()), 0700); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(ioutil.WriteFile(GlobalFilename(), content, 0600))
+}
+
+// Channel returns the release channel selected by the user.
+func Channel() string {
+	if Global.Channel == "" {
+		return ChannelStable
+	}
+	return Global.Channel
+}
+
+func ValidChannel(channel string) bool {
+	return channel == ChannelStable || channel == ChannelBeta
+}
+
+// Registry returns the repository configured to download the catalog images
+// or an empty string if there is none.
+func Registry() string {
+	if Settings.Registry != "" {
+		return Settings.Registry
+	}
+	return Global.Registry
+}
+
+// Mirrors returns the repositories to try in order when the registry cannot
+// download an image.
+func Mirrors() []string {
+	if len(Settings.Mirrors) > 0 {
+		return Settings.Mirrors
+	}
+	return Global.Mirrors
+}
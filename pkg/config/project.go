@@ -1,8 +1,12 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -10,6 +14,9 @@ import (
 var (
 	projectName    string
 	projectPackage string
+	projectID      string
+	projectRoot    string
+	legacyName     string
 )
 
 func init() {
@@ -17,17 +24,89 @@ func init() {
 		projectPackage = Settings.Project
 	}
 
-	root, err := os.Getwd()
+	wd, err := os.Getwd()
 	if err != nil {
 		log.Fatal(err)
 	}
-	projectName = filepath.Base(root)
+	legacyName = filepath.Base(wd)
+
+	projectRoot = findRoot(wd)
+	projectName = filepath.Base(projectRoot)
+	if projectPackage != "" {
+		projectName = path.Base(projectPackage)
+	}
+
+	// The hash of the root separates checkouts with the same name in different
+	// folders and the worktrees of the same repository.
+	hash := sha256.Sum256([]byte(projectRoot))
+	projectID = sanitizeName(projectName) + "-" + hex.EncodeToString(hash[:])[:8]
+}
+
+// findRoot returns the root of the repository that contains the directory or
+// the directory itself if it is not inside a repository.
+func findRoot(wd string) string {
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		// Worktrees have a .git file instead of a directory.
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return wd
+		}
+	}
 }
 
+// sanitizeName keeps only the characters allowed in the container names.
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	name = strings.TrimLeft(name, "_.-")
+	if name == "" {
+		return "project"
+	}
+	return name
+}
+
+// ProjectName returns the human readable name of the project.
 func ProjectName() string {
 	return projectName
 }
 
+// ProjectID returns the unique identifier of the project used to name its
+// containers, networks and caches.
+func ProjectID() string {
+	return projectID
+}
+
+// ProjectRoot returns the root directory of the repository of the project.
+func ProjectRoot() string {
+	return projectRoot
+}
+
+// LegacyProjectID returns the identifier used by older versions of actools,
+// the name of the working directory, to migrate their resources.
+func LegacyProjectID() string {
+	return legacyName
+}
+
+// ProjectCacheDir returns the directory where the tools store the artifacts of
+// the current project.
+func ProjectCacheDir() string {
+	return filepath.Join(Home(), ".actools", "cache-"+projectID)
+}
+
+// LegacyProjectCacheDir returns the cache directory used by older versions of actools.
+func LegacyProjectCacheDir() string {
+	return filepath.Join(Home(), ".actools", "cache-"+legacyName)
+}
+
 func ProjectPackage() string {
 	return projectPackage
 }
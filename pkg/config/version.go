@@ -1,3 +1,9 @@
 package config
 
-const Version = "dev"
+// Version, commit and date are injected by the release pipeline with
+// -ldflags -X when building the binary.
+var (
+	Version = "dev"
+	commit  string
+	date    string
+)
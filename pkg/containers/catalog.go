@@ -0,0 +1,84 @@
+package containers
+
+import (
+	"path/filepath"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/docker"
+)
+
+// CatalogDir is the directory of the actools repository with the Dockerfiles
+// of the catalog images.
+const CatalogDir = "containers"
+
+// BuildContext returns the directory with the Dockerfile of the image.
+func (container Container) BuildContext() string {
+	return filepath.Join(CatalogDir, container.Image)
+}
+
+// Tags lists every tag published for the image.
+func (container Container) Tags() []string {
+	return append([]string{docker.DefaultTag}, container.Versions...)
+}
+
+// ReleaseTag returns the immutable tag that identifies a tag of the image in a
+// release of the catalog. Latest uses the release name directly.
+func (container Container) ReleaseTag(tag, release string) string {
+	if tag == docker.DefaultTag {
+		return release
+	}
+	return tag + "-" + release
+}
+
+// BuildArgs returns the arguments to build a tag of the image. The latest tag
+// uses the defaults of the Dockerfile.
+func (container Container) BuildArgs(tag string) map[string]string {
+	if tag == docker.DefaultTag {
+		return nil
+	}
+	return map[string]string{"VERSION": tag}
+}
+
+// BuildPlatforms returns the platforms the image is published for.
+func (container Container) BuildPlatforms() []string {
+	if len(container.Platforms) == 0 {
+		return []string{docker.DefaultPlatform}
+	}
+	return container.Platforms
+}
+
+// CatalogImage returns the image that the catalog publishes for the tag.
+func (container Container) CatalogImage(tag string) *docker.ImageManager {
+	return docker.TaggedImage(Repo, container.Image, tag)
+}
+
+// CatalogDeps returns the other catalog images any tag of this one starts from.
+func (container Container) CatalogDeps() ([]string, error) {
+	dockerfile := filepath.Join(container.BuildContext(), "Dockerfile")
+
+	seen := make(map[string]bool)
+	var deps []string
+	for _, tag := range container.Tags() {
+		bases, err := docker.DockerfileBases(dockerfile, container.BuildArgs(tag))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, base := range bases {
+			if !strings.HasPrefix(base, Repo+"/") {
+				continue
+			}
+			name := strings.TrimPrefix(base, Repo+"/")
+			if idx := strings.IndexAny(name, ":@"); idx != -1 {
+				name = name[:idx]
+			}
+			if name != container.Image && !seen[name] {
+				seen[name] = true
+				deps = append(deps, name)
+			}
+		}
+	}
+
+	return deps, nil
+}
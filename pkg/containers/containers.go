@@ -1,19 +1,89 @@
 package containers
 
 import (
+	"sort"
+	"strings"
+
 	"libs.altipla.consulting/errors"
 
+	"github.com/altipla-consulting/actools/pkg/config"
 	"github.com/altipla-consulting/actools/pkg/docker"
+	"github.com/altipla-consulting/actools/pkg/lockfile"
 )
 
+// Repo is the default repository of the catalog images.
 const Repo = "eu.gcr.io/altipla-tools"
 
+// Registry returns the repository where the catalog images will be downloaded from.
+func Registry() string {
+	if registry := config.Registry(); registry != "" {
+		return registry
+	}
+	return Repo
+}
+
 type Container struct {
 	Image   string
 	Tools   []string
 	Options []docker.ContainerOption
+
+	// Versions lists the alternative tags published for the image that projects
+	// can select in the actools.yml file. The latest tag is always available.
+	Versions []string
+
+	// Platforms lists the platforms the image is published for. Images without
+	// them are only built for docker.DefaultPlatform.
+	Platforms []string
+
+	// PlatformArgs are the build args of each platform, normally to download
+	// binaries whose names do not follow the architectures of Docker.
+	PlatformArgs map[string]map[string]string
+}
+
+// Version returns the tag of the image selected by the project.
+func (container Container) Version() (string, error) {
+	version, ok := config.Settings.Versions[container.Image]
+	if !ok || version == docker.DefaultTag {
+		return docker.DefaultTag, nil
+	}
+
+	for _, v := range container.Versions {
+		if v == version {
+			return version, nil
+		}
+	}
+
+	return "", errors.Errorf("unsupported version %q of container %s, available versions: %s", version, container.Image, strings.Join(container.Versions, ", "))
+}
+
+// DockerImage returns the image of the container in the version selected by
+// the project, pinned to the digest of actools.lock if the project has one.
+func (container Container) DockerImage() (*docker.ImageManager, error) {
+	version, err := container.Version()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	image := docker.PinnedImage(Registry(), container.Image, version, lockfile.Current.Digest(container.Image, version))
+	image.AddMirrors(config.Mirrors()...)
+	return image, nil
+}
+
+// UnpinnedImage returns the image of the container in the version selected by
+// the project ignoring the digest of actools.lock.
+func (container Container) UnpinnedImage() (*docker.ImageManager, error) {
+	version, err := container.Version()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	image := docker.TaggedImage(Registry(), container.Image, version)
+	image.AddMirrors(config.Mirrors()...)
+	return image, nil
 }
 
+var multiArch = []string{"linux/amd64", "linux/arm64"}
+
 var containers = []Container{
 	{
 		Image:   "envoy",
@@ -38,6 +108,11 @@ var containers = []Container{
 			docker.WithSharedGopath(),
 			docker.WithStandardHome(),
 		},
+		Platforms: multiArch,
+		PlatformArgs: map[string]map[string]string{
+			"linux/amd64": {"GO_ARCH": "amd64"},
+			"linux/arm64": {"GO_ARCH": "arm64"},
+		},
 	},
 	{
 		Image: "gcloud",
@@ -49,6 +124,7 @@ var containers = []Container{
 			docker.WithSharedGcloud(),
 			docker.WithStandardHome(),
 		},
+		Platforms: multiArch,
 	},
 	{
 		Image: "go",
@@ -61,6 +137,8 @@ var containers = []Container{
 			docker.WithStandardHome(),
 			docker.WithSharedSSHSocket(),
 		},
+		Versions:  []string{"1.20", "1.21"},
+		Platforms: multiArch,
 	},
 	{
 		Image: "juice",
@@ -77,6 +155,7 @@ var containers = []Container{
 			docker.WithSharedWorkspace(),
 			docker.WithoutTTY(),
 		},
+		Versions: []string{"5.7", "8.0"},
 	},
 	{
 		Image: "mysqldump",
@@ -87,6 +166,7 @@ var containers = []Container{
 			docker.WithoutTTY(),
 			docker.WithStandardHome(),
 		},
+		Versions: []string{"5.7", "8.0"},
 	},
 	{
 		Image: "node",
@@ -96,7 +176,10 @@ var containers = []Container{
 			docker.WithLocalUser(),
 			docker.WithSharedSSHSocket(),
 			docker.WithStandardHome(),
+			docker.WithSharedNpmCache(),
 		},
+		Versions:  []string{"16", "18"},
+		Platforms: multiArch,
 	},
 	{
 		Image:   "phpmyadmin",
@@ -111,6 +194,12 @@ var containers = []Container{
 			docker.WithLocalUser(),
 			docker.WithSharedGopath(),
 			docker.WithStandardHome(),
+			docker.WithSharedPipCache(),
+		},
+		Platforms: multiArch,
+		PlatformArgs: map[string]map[string]string{
+			"linux/amd64": {"PROTOC_ARCH": "x86_64", "GRPC_BROWSER_ARCH": "amd64"},
+			"linux/arm64": {"PROTOC_ARCH": "aarch_64", "GRPC_BROWSER_ARCH": "arm64"},
 		},
 	},
 	{
@@ -119,6 +208,7 @@ var containers = []Container{
 		Options: []docker.ContainerOption{
 			docker.WithoutTTY(),
 		},
+		Platforms: multiArch,
 	},
 	{
 		Image: "migrator",
@@ -133,8 +223,10 @@ var containers = []Container{
 		Tools: []string{"php", "phpunit", "composer"},
 		Options: []docker.ContainerOption{
 			docker.WithSharedWorkspace(),
+			docker.WithLocalUser(),
 			docker.WithSharedGcloud(),
 			docker.WithStandardHome(),
+			docker.WithSharedComposerCache(),
 		},
 	},
 	{
@@ -148,9 +240,10 @@ var containers = []Container{
 		Options: []docker.ContainerOption{},
 	},
 	{
-		Image:   "pubsub",
-		Tools:   []string{},
-		Options: []docker.ContainerOption{},
+		Image:     "pubsub",
+		Tools:     []string{},
+		Options:   []docker.ContainerOption{},
+		Platforms: multiArch,
 	},
 	{
 		Image:   "ravendb",
@@ -193,3 +286,28 @@ func FindImage(image string) (Container, error) {
 
 	return Container{}, errors.Errorf("container not found: %s", image)
 }
+
+// ProjectImages returns the catalog images used by the current project, either
+// referenced from the actools.yml services and tools or pinned in actools.lock.
+func ProjectImages() []string {
+	used := make(map[string]bool)
+	for _, service := range config.Settings.Services {
+		used[service.Type] = true
+	}
+	for _, tool := range config.Settings.Tools {
+		used[tool.Container] = true
+	}
+	for image := range lockfile.Current.Images {
+		used[image] = true
+	}
+
+	var images []string
+	for _, container := range containers {
+		if used[container.Image] {
+			images = append(images, container.Image)
+		}
+	}
+	sort.Strings(images)
+
+	return images
+}
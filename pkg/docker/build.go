@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+)
+
+type BuildOption func(build *buildConfig)
+
+type buildConfig struct {
+	args    map[string]string
+	target  string
+	quiet   bool
+	force   bool
+	tracked map[string]bool
+
+	// platform is only set in the multi-platform builds.
+	platform string
+}
+
+func WithBuildArg(name, value string) BuildOption {
+	return func(build *buildConfig) {
+		if build.args == nil {
+			build.args = make(map[string]string)
+		}
+		build.args[name] = value
+	}
+}
+
+// WithBuildTarget builds only until the stage of a multi-stage Dockerfile.
+func WithBuildTarget(target string) BuildOption {
+	return func(build *buildConfig) {
+		build.target = target
+	}
+}
+
+// WithQuietBuild hides the output of the build except when it fails, so
+// multiple images can be built at the same time.
+func WithQuietBuild() BuildOption {
+	return func(build *buildConfig) {
+		build.quiet = true
+	}
+}
+
+// WithForceBuild builds the image even if the content did not change since the
+// last build.
+func WithForceBuild() BuildOption {
+	return func(build *buildConfig) {
+		build.force = true
+	}
+}
+
+// WithTrackedBases lists the base images built by actools, like the other
+// images of the project or the catalog. Rebuilding one of them rebuilds the
+// images that start from it. Other bases are not tracked, a new version of them
+// needs a forced build.
+func WithTrackedBases(images ...*ImageManager) BuildOption {
+	return func(build *buildConfig) {
+		if build.tracked == nil {
+			build.tracked = make(map[string]bool)
+		}
+		for _, image := range images {
+			build.tracked[image.local()] = true
+		}
+	}
+}
+
+// flags returns the arguments of docker build for the options.
+func (build *buildConfig) flags() []string {
+	var sh []string
+	for _, name := range sortedKeys(build.args) {
+		sh = append(sh, "--build-arg", fmt.Sprintf("%s=%s", name, build.args[name]))
+	}
+	if build.target != "" {
+		sh = append(sh, "--target", build.target)
+	}
+	return sh
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
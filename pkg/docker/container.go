@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh/terminal"
 	"libs.altipla.consulting/errors"
 
@@ -15,15 +16,18 @@ import (
 )
 
 type ContainerManager struct {
-	name         string
-	image        *ImageManager
-	network      *NetworkManager
-	localUser    bool
-	networkAlias string
-	noTTY        bool
-	env          map[string]string
-	volumes      map[string]string
-	ports        []string
+	name          string
+	legacyName    string
+	image         *ImageManager
+	network       *NetworkManager
+	legacyNetwork string
+	localUser     bool
+	networkAlias  string
+	noTTY         bool
+	env           map[string]string
+	secretEnv     map[string]string
+	volumes       map[string]string
+	ports         []string
 
 	// userWorkdir will overwrite workdir if specified
 	workdir     string
@@ -34,10 +38,12 @@ type ContainerManager struct {
 
 func Container(name string, options ...ContainerOption) (*ContainerManager, error) {
 	container := &ContainerManager{
-		name:    fmt.Sprintf("%s_%s", config.ProjectName(), name),
-		noTTY:   config.Jenkins(),
-		env:     make(map[string]string),
-		volumes: make(map[string]string),
+		name:       fmt.Sprintf("%s_%s", config.ProjectID(), name),
+		legacyName: fmt.Sprintf("%s_%s", config.LegacyProjectID(), name),
+		noTTY:      config.Jenkins(),
+		env:        make(map[string]string),
+		secretEnv:  make(map[string]string),
+		volumes:    make(map[string]string),
 	}
 
 	for _, option := range options {
@@ -58,18 +64,90 @@ func (container *ContainerManager) String() string {
 }
 
 func (container *ContainerManager) Exists() (bool, error) {
-	cmd := exec.Command("docker", "inspect", container.name)
-	if err := cmd.Run(); err != nil {
-		if !cmd.ProcessState.Success() {
-			return false, nil
-		}
+	exists, err := containerExists(container.name)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if exists || !container.persistent {
+		return exists, nil
+	}
+
+	return container.migrateLegacy()
+}
+
+func containerExists(name string) (bool, error) {
+	exists, err := inspectExists("container", "inspect", name)
+	return exists, errors.Trace(err)
+}
 
+// migrateLegacy renames the persistent containers created by older versions of
+// actools, named after the working directory, and moves them to the new network.
+// It returns true if there was a container to migrate.
+func (container *ContainerManager) migrateLegacy() (bool, error) {
+	if container.legacyName == container.name {
+		return false, nil
+	}
+	exists, err := containerExists(container.legacyName)
+	if err != nil {
 		return false, errors.Trace(err)
 	}
+	if !exists {
+		return false, nil
+	}
+
+	log.WithFields(log.Fields{
+		"from": container.legacyName,
+		"to":   container.name,
+	}).Info("Migrate container to the new project name")
+	if err := run.Interactive("docker", "rename", container.legacyName, container.name); err != nil {
+		return false, errors.Trace(err)
+	}
+
+	if container.network != nil {
+		if err := container.network.CreateIfNotExists(); err != nil {
+			return false, errors.Trace(err)
+		}
+		connect := []string{"network", "connect"}
+		if container.networkAlias != "" {
+			connect = append(connect, "--alias", container.networkAlias)
+		}
+		connect = append(connect, container.network.String(), container.name)
+		if err := run.Interactive("docker", connect...); err != nil {
+			return false, errors.Trace(err)
+		}
+
+		// The container may not be in the legacy network if the user changed it manually.
+		if container.legacyNetwork != "" && container.legacyNetwork != container.network.String() {
+			if err := run.Interactive("docker", "network", "disconnect", container.legacyNetwork, container.name); err != nil {
+				log.WithField("error", err.Error()).Debug("Cannot disconnect container from the legacy network")
+			}
+		}
+	}
 
 	return true, nil
 }
 
+// diagnose replaces the error of a failed docker command with a friendlier
+// one if the runtime is the cause. Failures of the commands running inside the
+// container are returned untouched without checking the runtime.
+func (container *ContainerManager) diagnose(err error) error {
+	if !dockerFailed(err) {
+		return errors.Trace(err)
+	}
+
+	if perr := Ping(); perr != nil {
+		return perr
+	}
+
+	if container.image != nil {
+		if exists, ierr := container.image.Exists(); ierr == nil && !exists {
+			return imageNotFound(container.image.String())
+		}
+	}
+
+	return errors.Trace(err)
+}
+
 func (container *ContainerManager) Running() (bool, error) {
 	exists, err := container.Exists()
 	if err != nil {
@@ -114,7 +192,11 @@ func (container *ContainerManager) Start(args ...string) error {
 		}
 	}
 
-	return errors.Trace(run.Interactive("docker", "start", container.name))
+	if err := run.Interactive("docker", "start", container.name); err != nil {
+		return container.diagnose(err)
+	}
+
+	return nil
 }
 
 func (container *ContainerManager) Kill() error {
@@ -147,7 +229,11 @@ func (container *ContainerManager) Run(args ...string) error {
 		return errors.Trace(err)
 	}
 
-	return errors.Trace(run.InteractiveWithOutput("docker", sh...))
+	if err := run.InteractiveWithOutput("docker", sh...); err != nil {
+		return container.diagnose(err)
+	}
+
+	return nil
 }
 
 func (container *ContainerManager) RunNonInteractive(args ...string) error {
@@ -156,7 +242,11 @@ func (container *ContainerManager) RunNonInteractive(args ...string) error {
 		return errors.Trace(err)
 	}
 
-	return errors.Trace(run.NonInteractiveWithOutput("docker", sh...))
+	if err := run.NonInteractiveWithOutput("docker", sh...); err != nil {
+		return container.diagnose(err)
+	}
+
+	return nil
 }
 
 func (container *ContainerManager) RunNonInteractiveCaptureOutput(lineToCapture int, args ...string) ([]string, error) {
@@ -166,7 +256,11 @@ func (container *ContainerManager) RunNonInteractiveCaptureOutput(lineToCapture
 	}
 
 	lines, err := run.NonInteractiveCaptureOutput(lineToCapture, "docker", sh...)
-	return lines, errors.Trace(err)
+	if err != nil {
+		return lines, container.diagnose(err)
+	}
+
+	return lines, nil
 }
 
 func (container *ContainerManager) buildCommand(interactive bool, operation string, args ...string) ([]string, error) {
@@ -182,6 +276,16 @@ func (container *ContainerManager) buildCommand(interactive bool, operation stri
 		return nil, errors.Trace(err)
 	}
 
+	// Los contenedores transitorios no pueden coincidir con otro que ya exista,
+	// normalmente otra ejecución de la misma herramienta.
+	if operation == "run" {
+		if exists, err := container.Exists(); err != nil {
+			return nil, errors.Trace(err)
+		} else if exists {
+			return nil, nameConflict(container.name)
+		}
+	}
+
 	var sh []string
 
 	sh = append(sh, operation)
@@ -222,6 +326,15 @@ func (container *ContainerManager) buildCommand(interactive bool, operation stri
 		sh = append(sh, "-e", fmt.Sprintf("%v=%v", k, v))
 	}
 
+	// Los secretos no aparecen en los argumentos del comando. Docker copia el
+	// valor de la variable del entorno del cliente, que hereda del nuestro.
+	for k, v := range container.secretEnv {
+		if err := os.Setenv(k, v); err != nil {
+			return nil, errors.Trace(err)
+		}
+		sh = append(sh, "-e", k)
+	}
+
 	// Red en la que se ejecutará el contenedor y que permitirá con el DNS interno
 	// comunicarse a los servicios los unos con los otros.
 	if container.network != nil {
@@ -249,8 +362,18 @@ func (container *ContainerManager) buildCommand(interactive bool, operation stri
 		sh = append(sh, "-w", container.workdir)
 	}
 
+	// En modo offline nunca descargamos imágenes implícitamente. Los comandos
+	// comprueban antes de empezar que todas las imágenes estén disponibles.
+	if config.Offline() {
+		sh = append(sh, "--pull=never")
+	}
+
 	// Añadimos la imagen que ejecutamos.
-	sh = append(sh, container.image.String())
+	ref, err := container.image.LocalReference()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sh = append(sh, ref)
 
 	// Añadimos cualquier adicional que recibamos en el momento.
 	sh = append(sh, args...)
@@ -270,5 +393,9 @@ func (container *ContainerManager) Create(args ...string) error {
 		return errors.Trace(err)
 	}
 
-	return errors.Trace(run.Interactive("docker", sh...))
+	if err := run.Interactive("docker", sh...); err != nil {
+		return container.diagnose(err)
+	}
+
+	return nil
 }
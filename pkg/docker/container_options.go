@@ -8,6 +8,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"libs.altipla.consulting/errors"
 
+	"github.com/altipla-consulting/actools/pkg/cache"
 	"github.com/altipla-consulting/actools/pkg/config"
 )
 
@@ -22,13 +23,8 @@ func WithNetwork(network *NetworkManager) ContainerOption {
 
 func WithDefaultNetwork() ContainerOption {
 	return func(container *ContainerManager) error {
-		wd, err := os.Getwd()
-		if err != nil {
-			return errors.Trace(err)
-		}
-
-		container.network = Network(filepath.Base(wd) + "_default")
-
+		container.network = Network(config.ProjectID() + "_default")
+		container.legacyNetwork = config.LegacyProjectID() + "_default"
 		return nil
 	}
 }
@@ -105,6 +101,15 @@ func WithEnv(name, value string) ContainerOption {
 	}
 }
 
+// WithSecretEnv passes the variable to the container without writing its value
+// in the command line.
+func WithSecretEnv(name, value string) ContainerOption {
+	return func(container *ContainerManager) error {
+		container.secretEnv[name] = value
+		return nil
+	}
+}
+
 func WithVolume(source, inside string) ContainerOption {
 	return func(container *ContainerManager) error {
 		container.volumes[source] = inside
@@ -146,21 +151,41 @@ func WithPorts(desc string) ContainerOption {
 	}
 }
 
+// WithSharedGopath should be applied after WithImage because the cache directories
+// are separated by the version of the image.
 func WithSharedGopath() ContainerOption {
 	return func(container *ContainerManager) error {
-		hostBin := fmt.Sprintf("%s/.actools/cache-%s/bin", config.Home(), config.ProjectName())
+		if container.image == nil {
+			return errors.Errorf("shared gopath requires the image of the container")
+		}
+		if err := cache.MarkProject(); err != nil {
+			return errors.Trace(err)
+		}
+
+		// Artifacts compiled with different versions of the tools cannot be mixed. The
+		// latest version keeps the root of the cache to conserve existing directories.
+		cacheDir := config.ProjectCacheDir()
+		if tag := container.image.Tag(); tag != DefaultTag {
+			cacheDir = filepath.Join(cacheDir, fmt.Sprintf("%s-%s", container.image.Base(), tag))
+		}
+
+		hostBin := filepath.Join(cacheDir, "bin")
 		container.volumes[hostBin] = "/go/bin"
 		if err := os.MkdirAll(hostBin, 0777); err != nil {
 			return errors.Trace(err)
 		}
 
-		hostPkg := fmt.Sprintf("%s/.actools/cache-%s/pkg", config.Home(), config.ProjectName())
-		container.volumes[hostPkg] = "/go/pkg"
-		if err := os.MkdirAll(hostPkg, 0777); err != nil {
+		// Downloaded modules do not depend on the project nor the Go version.
+		if err := cache.MigrateModules(filepath.Join(cacheDir, "pkg")); err != nil {
+			return errors.Trace(err)
+		}
+		hostMod := cache.SharedModulesDir()
+		container.volumes[hostMod] = "/go/pkg/mod"
+		if err := os.MkdirAll(hostMod, 0777); err != nil {
 			return errors.Trace(err)
 		}
 
-		cachePkg := fmt.Sprintf("%s/.actools/cache-%s/cache", config.Home(), config.ProjectName())
+		cachePkg := filepath.Join(cacheDir, "cache")
 		container.volumes[cachePkg] = "/home/container/.cache"
 		if err := os.MkdirAll(cachePkg, 0777); err != nil {
 			return errors.Trace(err)
@@ -187,6 +212,40 @@ func WithSharedGopath() ContainerOption {
 	}
 }
 
+// WithSharedNpmCache keeps the packages downloaded by npm between runs.
+func WithSharedNpmCache() ContainerOption {
+	return withLanguageCache("npm", "/home/container/.npm", "npm_config_cache")
+}
+
+// WithSharedComposerCache keeps the packages downloaded by composer between runs.
+func WithSharedComposerCache() ContainerOption {
+	return withLanguageCache("composer", "/home/container/.composer-cache", "COMPOSER_CACHE_DIR")
+}
+
+// WithSharedPipCache keeps the packages downloaded by pip between runs.
+func WithSharedPipCache() ContainerOption {
+	return withLanguageCache("pip", "/home/container/.pip-cache", "PIP_CACHE_DIR")
+}
+
+// withLanguageCache shares a directory of the project cache with the package
+// manager of a language, configuring its location with an environment variable.
+func withLanguageCache(name, inside, env string) ContainerOption {
+	return func(container *ContainerManager) error {
+		if err := cache.MarkProject(); err != nil {
+			return errors.Trace(err)
+		}
+
+		host := filepath.Join(config.ProjectCacheDir(), name)
+		if err := os.MkdirAll(host, 0777); err != nil {
+			return errors.Trace(err)
+		}
+		container.volumes[host] = inside
+		container.env[env] = inside
+
+		return nil
+	}
+}
+
 func WithWorkdir(workdir string) ContainerOption {
 	return func(container *ContainerManager) error {
 		container.userWorkdir = workdir
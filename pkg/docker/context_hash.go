@@ -0,0 +1,192 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"libs.altipla.consulting/errors"
+)
+
+// ContextHashLabel stores in the built images the hash of the content used to
+// build them.
+const ContextHashLabel = "consulting.altipla.actools.context-hash"
+
+// contextHash computes a deterministic hash of everything that affects the
+// result of a build: the files of the context not excluded by .dockerignore,
+// the Dockerfile, the build options and the local images it starts from.
+func contextHash(context, dockerfile string, build *buildConfig) (string, error) {
+	ignore, err := readDockerIgnore(context)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	h := sha256.New()
+	err = filepath.Walk(context, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel, err := filepath.Rel(context, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if ignore.Excluded(rel) {
+			// Directories can only be skipped if no exception could include again
+			// some file inside them.
+			if info.IsDir() && !ignore.HasExceptions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			fmt.Fprintf(h, "link %s %s\n", rel, target)
+
+		case info.Mode().IsRegular():
+			fmt.Fprintf(h, "file %s %o %d\n", rel, info.Mode().Perm(), info.Size())
+			f, err := os.Open(path)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer f.Close()
+			if _, err := io.Copy(h, f); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	f, err := os.Open(dockerfile)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer f.Close()
+	fmt.Fprintf(h, "dockerfile\n")
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Trace(err)
+	}
+
+	for _, name := range sortedKeys(build.args) {
+		fmt.Fprintf(h, "arg %s=%s\n", name, build.args[name])
+	}
+	fmt.Fprintf(h, "target %s\n", build.target)
+	if build.platform != "" {
+		fmt.Fprintf(h, "platform %s\n", build.platform)
+	}
+
+	// Rebuilding a tracked base image should rebuild the images that start from
+	// it. They are built before, so they must exist. Other bases are ignored in
+	// local builds to keep the hash stable before and after pulling them.
+	// Multi-platform builds always start from the registry and hash the digest
+	// published there, so a new upstream version of a floating tag like node:18
+	// rebuilds the images too.
+	bases, err := DockerfileBases(dockerfile, build.args)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	for _, base := range bases {
+		ref := withDefaultTag(base)
+		if !build.tracked[ref] {
+			if build.platform != "" {
+				digest, err := registryDigest(ref)
+				if err != nil {
+					return "", errors.Trace(err)
+				}
+				fmt.Fprintf(h, "external %s %s\n", ref, digest)
+			}
+			continue
+		}
+		id, err := baseID(ref, build.platform != "")
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		fmt.Fprintf(h, "base %s %s\n", ref, id)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// baseID identifies the content of a tracked base image. Multi-platform builds
+// start from the published images instead of the local ones and use the context
+// hash of their manifest.
+func baseID(ref string, published bool) (string, error) {
+	if published {
+		hash := publishedHash(ref)
+		if hash == "" {
+			return "", errors.Errorf("base image %s was not published by actools", ref)
+		}
+		return hash, nil
+	}
+
+	output, err := exec.Command("docker", "image", "inspect", "-f", "{{.Id}}", ref).CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot inspect base image %s: %s", ref, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+var (
+	registryDigestsMu sync.Mutex
+
+	// registryDigests keeps the digests already resolved, the platforms and tags
+	// of the catalog share most of their bases.
+	registryDigests = map[string]string{}
+)
+
+// registryDigest returns the digest of the manifest published in the registry
+// for an external base image.
+func registryDigest(ref string) (string, error) {
+	registryDigestsMu.Lock()
+	defer registryDigestsMu.Unlock()
+
+	if digest, ok := registryDigests[ref]; ok {
+		return digest, nil
+	}
+	output, err := exec.Command("docker", "buildx", "imagetools", "inspect", "--raw", ref).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot inspect base image %s in the registry", ref)
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(output))
+	registryDigests[ref] = digest
+	return digest, nil
+}
+
+// withDefaultTag adds the latest tag to the references without tag or digest.
+func withDefaultTag(ref string) string {
+	if strings.Contains(ref, "@") || strings.LastIndex(ref, ":") > strings.LastIndex(ref, "/") {
+		return ref
+	}
+	return ref + ":" + DefaultTag
+}
+
+// builtHash returns the context hash stored in the local image or an empty
+// string if the image does not exist or was not built by actools.
+func (image *ImageManager) builtHash() string {
+	format := fmt.Sprintf(`{{index .Config.Labels %q}}`, ContextHashLabel)
+	output, err := exec.Command("docker", "image", "inspect", "-f", format, image.local()).Output()
+	if err != nil {
+		return ""
+	}
+	hash := strings.TrimSpace(string(output))
+	if hash == "<no value>" {
+		return ""
+	}
+	return hash
+}
@@ -0,0 +1,167 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeContext(t *testing.T, files map[string]string) string {
+	t.Helper()
+	context := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(context, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return context
+}
+
+func hashContext(t *testing.T, context string, options ...BuildOption) string {
+	t.Helper()
+	build := new(buildConfig)
+	for _, option := range options {
+		option(build)
+	}
+	hash, err := contextHash(context, filepath.Join(context, "Dockerfile"), build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+var baseContext = map[string]string{
+	"Dockerfile":    "FROM golang:1.21\nCOPY . .\n",
+	".dockerignore": "*.[oa]\nnode_modules\n",
+	"main.go":       "package main\n",
+	"pkg/foo.go":    "package pkg\n",
+}
+
+func TestContextHashStable(t *testing.T) {
+	first := writeContext(t, baseContext)
+	second := writeContext(t, baseContext)
+
+	// The location and modification times of the files do not change the hash.
+	old := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(second, "main.go"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if hashContext(t, first) != hashContext(t, second) {
+		t.Error("the same content should have the same hash")
+	}
+	if hashContext(t, first) != hashContext(t, first) {
+		t.Error("repeated hashes of the same context should match")
+	}
+}
+
+func TestContextHashExcludedFiles(t *testing.T) {
+	context := writeContext(t, baseContext)
+	before := hashContext(t, context)
+
+	for _, name := range []string{"main.o", "lib.a", "node_modules/foo/index.js"} {
+		filename := filepath.Join(context, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte("ignored"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if hashContext(t, context) != before {
+		t.Error("files excluded by .dockerignore should not change the hash")
+	}
+}
+
+func TestContextHashChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, context string) []BuildOption
+	}{
+		{
+			name: "file content",
+			change: func(t *testing.T, context string) []BuildOption {
+				if err := ioutil.WriteFile(filepath.Join(context, "main.go"), []byte("package foo\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "new file",
+			change: func(t *testing.T, context string) []BuildOption {
+				if err := ioutil.WriteFile(filepath.Join(context, "pkg", "bar.go"), []byte("package pkg\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "file mode",
+			change: func(t *testing.T, context string) []BuildOption {
+				if err := os.Chmod(filepath.Join(context, "main.go"), 0755); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "symlink",
+			change: func(t *testing.T, context string) []BuildOption {
+				if err := os.Symlink("main.go", filepath.Join(context, "link.go")); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "dockerfile",
+			change: func(t *testing.T, context string) []BuildOption {
+				if err := ioutil.WriteFile(filepath.Join(context, "Dockerfile"), []byte("FROM golang:1.22\nCOPY . .\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "build arg",
+			change: func(t *testing.T, context string) []BuildOption {
+				return []BuildOption{WithBuildArg("VERSION", "1")}
+			},
+		},
+		{
+			name: "target",
+			change: func(t *testing.T, context string) []BuildOption {
+				return []BuildOption{WithBuildTarget("test")}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			context := writeContext(t, baseContext)
+			before := hashContext(t, context)
+
+			options := test.change(t, context)
+			if hashContext(t, context, options...) == before {
+				t.Error("the hash should change")
+			}
+		})
+	}
+}
+
+func TestContextHashUntrackedBases(t *testing.T) {
+	context := writeContext(t, baseContext)
+
+	// External bases are not inspected, so the hash does not need docker.
+	before := hashContext(t, context)
+	if hashContext(t, context, WithTrackedBases(Image("eu.gcr.io/altipla-tools", "go"))) != before {
+		t.Error("untracked bases should not change the hash")
+	}
+}
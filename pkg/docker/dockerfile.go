@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+var dockerfileVar = regexp.MustCompile(`\$\{?([a-zA-Z_][a-zA-Z0-9_]*)(:-([^}]*))?\}?`)
+
+// DockerfileBases returns the images a Dockerfile starts from, ignoring the
+// previous stages of the same file. Variables are resolved with the build args
+// and the defaults of the ARG instructions declared before the first FROM.
+func DockerfileBases(dockerfile string, args map[string]string) ([]string, error) {
+	f, err := os.Open(dockerfile)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	stages := make(map[string]bool)
+	var bases []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			if len(bases) > 0 {
+				continue
+			}
+			parts := strings.SplitN(fields[1], "=", 2)
+			if value, ok := args[parts[0]]; ok {
+				vars[parts[0]] = value
+			} else if len(parts) == 2 {
+				vars[parts[0]] = strings.Trim(parts[1], `"'`)
+			}
+
+		case "FROM":
+			fields = fields[1:]
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:]
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			base := dockerfileVar.ReplaceAllStringFunc(fields[0], func(match string) string {
+				groups := dockerfileVar.FindStringSubmatch(match)
+				if value, ok := vars[groups[1]]; ok {
+					return value
+				}
+				return groups[3]
+			})
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+				stages[fields[2]] = true
+			}
+			if base == "scratch" || stages[base] {
+				bases = append(bases, "")
+				continue
+			}
+			bases = append(bases, base)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Empty entries only mark that the first FROM was already found.
+	var result []string
+	for _, base := range bases {
+		if base != "" {
+			result = append(result, base)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDockerfileBases(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		args       map[string]string
+		bases      []string
+	}{
+		{
+			name:       "single stage",
+			dockerfile: "FROM golang:1.21\nRUN go build\n",
+			bases:      []string{"golang:1.21"},
+		},
+		{
+			name:       "lowercase instructions",
+			dockerfile: "from golang:1.21 as builder\n",
+			bases:      []string{"golang:1.21"},
+		},
+		{
+			name:       "previous stages",
+			dockerfile: "FROM golang:1.21 AS builder\nFROM builder AS test\nFROM debian:11\nCOPY --from=builder /app /app\n",
+			bases:      []string{"golang:1.21", "debian:11"},
+		},
+		{
+			name:       "scratch",
+			dockerfile: "FROM golang AS builder\nFROM scratch\n",
+			bases:      []string{"golang"},
+		},
+		{
+			name:       "platform flag",
+			dockerfile: "FROM --platform=$BUILDPLATFORM golang AS builder\n",
+			bases:      []string{"golang"},
+		},
+		{
+			name:       "arg defaults",
+			dockerfile: "ARG VERSION=1.21\nARG REPO=\"golang\"\nFROM ${REPO}:$VERSION\n",
+			bases:      []string{"golang:1.21"},
+		},
+		{
+			name:       "build args override defaults",
+			dockerfile: "ARG VERSION=1.21\nFROM golang:${VERSION}\n",
+			args:       map[string]string{"VERSION": "1.22"},
+			bases:      []string{"golang:1.22"},
+		},
+		{
+			name:       "inline default",
+			dockerfile: "FROM golang:${VERSION:-1.20}\n",
+			bases:      []string{"golang:1.20"},
+		},
+		{
+			name:       "args after the first FROM are not global",
+			dockerfile: "FROM golang\nARG BASE=debian\nFROM $BASE\n",
+			bases:      []string{"golang"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+			if err := ioutil.WriteFile(dockerfile, []byte(test.dockerfile), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			bases, err := DockerfileBases(dockerfile, test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(bases, test.bases) {
+				t.Errorf("bases = %q, want %q", bases, test.bases)
+			}
+		})
+	}
+}
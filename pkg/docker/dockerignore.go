@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+type ignorePattern struct {
+	// segments are the parts of the pattern between slashes. Each one uses the
+	// syntax of path.Match, except ** that matches any number of directories.
+	segments []string
+	exclude  bool
+}
+
+// dockerIgnore reproduces the rules of .dockerignore: the last pattern that
+// matches a path or any of its parent directories decides, and patterns
+// starting with ! include the files again.
+type dockerIgnore struct {
+	patterns []*ignorePattern
+}
+
+func readDockerIgnore(context string) (*dockerIgnore, error) {
+	ignore := new(dockerIgnore)
+
+	f, err := os.Open(filepath.Join(context, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ignore, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern := &ignorePattern{exclude: true}
+		if strings.HasPrefix(line, "!") {
+			pattern.exclude = false
+			line = strings.TrimSpace(line[1:])
+		}
+		line = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(line)), "/")
+
+		pattern.segments = strings.Split(line, "/")
+		for _, segment := range pattern.segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid .dockerignore pattern: %s", line)
+			}
+		}
+		ignore.patterns = append(ignore.patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return ignore, nil
+}
+
+// match checks a path split in segments against the segments of a pattern.
+func (pattern *ignorePattern) match(segments []string) bool {
+	return matchSegments(pattern.segments, segments)
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	// ** matches zero or more directories.
+	if pattern[0] == "**" {
+		if matchSegments(pattern[1:], segments) {
+			return true
+		}
+		return len(segments) > 0 && matchSegments(pattern, segments[1:])
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	// Inside a segment ** cannot cross directories and it is the same as *.
+	matched, err := path.Match(strings.ReplaceAll(pattern[0], "**", "*"), segments[0])
+	if err != nil || !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// Excluded checks a path relative to the context with forward slashes.
+func (ignore *dockerIgnore) Excluded(rel string) bool {
+	parents := []string{rel}
+	for dir := filepath.ToSlash(filepath.Dir(rel)); dir != "."; dir = filepath.ToSlash(filepath.Dir(dir)) {
+		parents = append(parents, dir)
+	}
+
+	var excluded bool
+	for _, pattern := range ignore.patterns {
+		for _, candidate := range parents {
+			if pattern.match(strings.Split(candidate, "/")) {
+				excluded = pattern.exclude
+				break
+			}
+		}
+	}
+	return excluded
+}
+
+func (ignore *dockerIgnore) HasExceptions() bool {
+	for _, pattern := range ignore.patterns {
+		if !pattern.exclude {
+			return true
+		}
+	}
+	return false
+}
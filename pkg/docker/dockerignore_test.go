@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func writeDockerIgnore(t *testing.T, content string) *dockerIgnore {
+	t.Helper()
+	context := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(context, ".dockerignore"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ignore, err := readDockerIgnore(context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ignore
+}
+
+func TestExcluded(t *testing.T) {
+	tests := []struct {
+		patterns string
+		path     string
+		excluded bool
+	}{
+		{"node_modules", "node_modules", true},
+		{"node_modules", "node_modules/foo/index.js", true},
+		{"node_modules", "src/node_modules", false},
+		{"/node_modules", "node_modules/foo", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/README.md", false},
+		{"*/*.md", "docs/README.md", true},
+		{"**/*.md", "README.md", true},
+		{"**/*.md", "docs/api/README.md", true},
+		{"docs/**", "docs/api/README.md", true},
+		{"docs/**/*.png", "docs/logo.png", true},
+		{"docs/**/*.png", "docs/img/logo.png", true},
+		{"docs/**/*.png", "src/logo.png", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"*.[oa]", "main.o", true},
+		{"*.[oa]", "lib.a", true},
+		{"*.[oa]", "main.c", false},
+		{"[^a]*", "build", true},
+		{"[^a]*", "app", false},
+		{"[a-c]*.txt", "b.txt", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+		{"foo.txt", "foo.txt.bak", false},
+		{"*.log\n!important.log", "important.log", false},
+		{"*.log\n!important.log", "debug.log", true},
+		{"!important.log\n*.log", "important.log", true},
+		{"build\n!build/keep", "build/keep", false},
+		{"build\n!build/keep", "build/other", true},
+		{"./tmp", "tmp/foo", true},
+		{"# comment\n\n  tmp  ", "tmp", true},
+	}
+	for _, test := range tests {
+		ignore := writeDockerIgnore(t, test.patterns)
+		if got := ignore.Excluded(test.path); got != test.excluded {
+			t.Errorf("patterns %q: Excluded(%q) = %v, want %v", test.patterns, test.path, got, test.excluded)
+		}
+	}
+}
+
+func TestExcludedWithoutDockerIgnore(t *testing.T) {
+	ignore, err := readDockerIgnore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ignore.Excluded("foo") {
+		t.Error("files should not be excluded without .dockerignore")
+	}
+	if ignore.HasExceptions() {
+		t.Error("unexpected exceptions without .dockerignore")
+	}
+}
+
+func TestInvalidDockerIgnore(t *testing.T) {
+	context := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(context, ".dockerignore"), []byte("*.[oa\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readDockerIgnore(context); err == nil {
+		t.Error("expected error with an invalid pattern")
+	}
+}
@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+var (
+	ErrDaemonUnavailable = errors.New("docker daemon unavailable")
+	ErrPermissionDenied  = errors.New("docker permission denied")
+	ErrImageNotFound     = errors.New("docker image not found")
+	ErrNameConflict      = errors.New("docker container name conflict")
+)
+
+// RuntimeError explains to the user a failure of the docker runtime and how to
+// fix it. Compare it with the Err* variables using errors.Is.
+type RuntimeError struct {
+	kind        error
+	Explanation string
+	Fix         string
+}
+
+func (err *RuntimeError) Error() string {
+	return fmt.Sprintf("%s\nFix: %s", err.Explanation, err.Fix)
+}
+
+func (err *RuntimeError) Unwrap() error {
+	return err.kind
+}
+
+func daemonUnavailable() *RuntimeError {
+	return &RuntimeError{
+		kind:        ErrDaemonUnavailable,
+		Explanation: "The docker daemon is not running or docker is not installed.",
+		Fix:         "Start the daemon with `sudo systemctl start docker` or install Docker following https://docs.docker.com/engine/install/",
+	}
+}
+
+func permissionDenied() *RuntimeError {
+	return &RuntimeError{
+		kind:        ErrPermissionDenied,
+		Explanation: "Your user cannot access the docker socket.",
+		Fix:         "Add your user to the docker group with `sudo usermod -aG docker $USER` and log in again.",
+	}
+}
+
+func imageNotFound(image string) *RuntimeError {
+	return &RuntimeError{
+		kind:        ErrImageNotFound,
+		Explanation: fmt.Sprintf("The image %s does not exist locally and it cannot be downloaded.", image),
+		Fix:         "Check the versions and registry of actools.yml, your registry credentials (`actools gcloud auth configure-docker`) and run `actools pull`.",
+	}
+}
+
+func nameConflict(name string) *RuntimeError {
+	return &RuntimeError{
+		kind:        ErrNameConflict,
+		Explanation: fmt.Sprintf("There is another container with the name %s, probably another actools command running the same tool.", name),
+		Fix:         fmt.Sprintf("Wait until the other command finishes or remove the container with `docker rm -f %s`.", name),
+	}
+}
+
+// classifyOutput recognizes the common failures of the runtime in the output of
+// a docker command. It returns nil if the output is not a known failure.
+func classifyOutput(output, image string) *RuntimeError {
+	switch {
+	case strings.Contains(output, "Cannot connect to the Docker daemon"), strings.Contains(output, "Is the docker daemon running"):
+		return daemonUnavailable()
+
+	case strings.Contains(output, "permission denied while trying to connect to the Docker daemon"):
+		return permissionDenied()
+
+	case strings.Contains(output, "is already in use by container"):
+		return nameConflict(conflictName(output))
+
+	case image != "" && (strings.Contains(output, "manifest unknown") ||
+		strings.Contains(output, "pull access denied") ||
+		strings.Contains(output, "repository does not exist") ||
+		strings.Contains(output, "not found: manifest")):
+		return imageNotFound(image)
+	}
+
+	return nil
+}
+
+// inspectExists runs a docker inspect command and reports if the object exists.
+// Once the known failures of the runtime are discarded, docker exits with status
+// 1 only when the object is missing. Any other failure is returned.
+func inspectExists(args ...string) (bool, error) {
+	output, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		if rerr := classifyOutput(string(output), ""); rerr != nil {
+			return false, rerr
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+
+		return false, errors.Wrapf(err, "docker %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+
+	return true, nil
+}
+
+// dockerFailed returns true if the error comes from docker itself instead of the
+// command running inside the container. Docker exits with status 125 when it
+// cannot run the container.
+func dockerFailed(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 125
+}
+
+// conflictName extracts the container name of a conflict message like:
+// Conflict. The container name "/foo" is already in use by container "abc".
+func conflictName(output string) string {
+	parts := strings.SplitN(output, `"`, 3)
+	if len(parts) < 3 {
+		return "(unknown)"
+	}
+	return strings.TrimPrefix(parts[1], "/")
+}
+
+// Ping checks that the docker daemon is running and accessible.
+func Ping() error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return daemonUnavailable()
+	}
+
+	output, err := exec.Command("docker", "info", "--format", "{{.ServerVersion}}").CombinedOutput()
+	if err != nil {
+		if rerr := classifyOutput(string(output), ""); rerr != nil {
+			return rerr
+		}
+		return errors.Wrapf(err, "docker info failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -1,28 +1,153 @@
 package docker
 
 import (
+	"encoding/json"
 	"fmt"
+	"os/exec"
 	"path"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"libs.altipla.consulting/errors"
 
+	"github.com/altipla-consulting/actools/pkg/config"
 	"github.com/altipla-consulting/actools/pkg/run"
 )
 
+const DefaultTag = "latest"
+
 type ImageManager struct {
 	name string
+	tag  string
+
+	// digest pins the image to a specific content if specified.
+	digest string
+
+	// mirrors are alternative repositories with the same images to download
+	// them when the main one fails.
+	mirrors []string
 }
 
 func Image(repo, name string) *ImageManager {
+	return TaggedImage(repo, name, DefaultTag)
+}
+
+func TaggedImage(repo, name, tag string) *ImageManager {
 	return &ImageManager{
 		name: fmt.Sprintf("%s/%s", repo, name),
+		tag:  tag,
 	}
 }
 
+// PinnedImage returns an image that will always be referenced by its digest
+// instead of the mutable tag. An empty digest returns an unpinned image.
+func PinnedImage(repo, name, tag, digest string) *ImageManager {
+	image := TaggedImage(repo, name, tag)
+	image.digest = digest
+	return image
+}
+
+// AddMirrors configures alternative repositories that will be tried in order if
+// the main one cannot download the image.
+func (image *ImageManager) AddMirrors(mirrors ...string) {
+	image.mirrors = append(image.mirrors, mirrors...)
+}
+
+type ImageInfo struct {
+	ID          string    `json:"Id"`
+	RepoDigests []string  `json:"RepoDigests"`
+	Created     time.Time `json:"Created"`
+
+	Os           string `json:"Os"`
+	Architecture string `json:"Architecture"`
+	Variant      string `json:"Variant"`
+}
+
 func (image *ImageManager) Pull() error {
-	return errors.Trace(run.InteractiveWithOutput("docker", "pull", image.String()))
+	err := image.pullWithMirrors(func(ref string) error {
+		if err := run.InteractiveWithOutput("docker", "pull", ref); err != nil {
+			if perr := Ping(); perr != nil {
+				return perr
+			}
+			if !foreignDaemon() {
+				return errors.Trace(err)
+			}
+			logDefaultPlatform(ref)
+			if err := run.InteractiveWithOutput("docker", "pull", "--platform", DefaultPlatform, ref); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(image.recordPlatform())
+}
+
+// PullQuiet downloads the image without printing the progress bars, so multiple
+// images can be downloaded at the same time.
+func (image *ImageManager) PullQuiet() error {
+	err := image.pullWithMirrors(func(ref string) error {
+		log.WithField("image", ref).Debug("Pull image quietly")
+
+		output, err := exec.Command("docker", "pull", "--quiet", ref).CombinedOutput()
+		if err != nil && strings.Contains(string(output), "no matching manifest") {
+			logDefaultPlatform(ref)
+			output, err = exec.Command("docker", "pull", "--quiet", "--platform", DefaultPlatform, ref).CombinedOutput()
+		}
+		if err != nil {
+			if rerr := classifyOutput(string(output), ref); rerr != nil {
+				return rerr
+			}
+			return errors.Wrapf(err, "cannot pull image %s: %s", ref, strings.TrimSpace(string(output)))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(image.recordPlatform())
+}
+
+func (image *ImageManager) pullWithMirrors(pull func(ref string) error) error {
+	if config.Offline() {
+		return errors.Errorf("cannot download image %s in offline mode", image)
+	}
+
+	err := pull(image.String())
+	if err == nil {
+		return nil
+	}
+
+	for _, mirror := range image.mirrors {
+		log.WithFields(log.Fields{
+			"image":  image.String(),
+			"mirror": mirror,
+			"error":  err.Error(),
+		}).Warning("Cannot download image, trying the next mirror")
+
+		ref := image.reference(path.Join(mirror, image.Base()))
+		if err = pull(ref); err != nil {
+			continue
+		}
+
+		// Tag the mirror copy with the main name to run it normally. Digests cannot
+		// be tagged, LocalReference will find the mirror copy instead.
+		if image.digest == "" {
+			if err := run.Interactive("docker", "tag", ref, image.String()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+		return nil
+	}
+
+	return errors.Trace(err)
 }
 
 func (image *ImageManager) Push(tag string) error {
@@ -32,29 +157,62 @@ func (image *ImageManager) Push(tag string) error {
 	}).Info("Push image")
 
 	taggedName := fmt.Sprintf("%s:%s", image.name, tag)
-	if err := run.InteractiveWithOutput("docker", "tag", image.name, taggedName); err != nil {
+	if err := run.InteractiveWithOutput("docker", "tag", image.local(), taggedName); err != nil {
 		return errors.Trace(err)
 	}
 
 	return errors.Trace(run.InteractiveWithOutput("docker", "push", taggedName))
 }
 
-func (image *ImageManager) Build(context, dockerfile string) error {
+func (image *ImageManager) Build(context, dockerfile string, options ...BuildOption) error {
+	build := new(buildConfig)
+	for _, option := range options {
+		option(build)
+	}
+
+	if dockerfile == "" {
+		dockerfile = path.Join(context, "Dockerfile")
+	}
+
+	// Images built from the same content keep their ID, and the tags computed
+	// from it, instead of being rebuilt and pushed again.
+	hash, err := contextHash(context, dockerfile, build)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !build.force && image.builtHash() == hash {
+		log.WithField("name", image.local()).Info("Image up to date, skipping build")
+		return nil
+	}
+
 	log.WithFields(log.Fields{
 		"context":    context,
 		"dockerfile": dockerfile,
-		"name":       image.name,
+		"name":       image.local(),
+		"target":     build.target,
 	}).Info("Build image")
 
-	if dockerfile == "" {
-		dockerfile = path.Join(context, "Dockerfile")
+	sh := []string{"build", "-t", image.local(), "-f", dockerfile, "--label", fmt.Sprintf("%s=%s", ContextHashLabel, hash)}
+	sh = append(sh, build.flags()...)
+	sh = append(sh, context)
+
+	return errors.Trace(image.runBuild(build, image.local(), sh))
+}
+
+func (image *ImageManager) runBuild(build *buildConfig, ref string, sh []string) error {
+	if build.quiet {
+		output, err := exec.Command("docker", sh...).CombinedOutput()
+		if err != nil {
+			return errors.Wrapf(err, "cannot build image %s:\n%s", ref, strings.TrimSpace(string(output)))
+		}
+		return nil
 	}
 
-	return errors.Trace(run.InteractiveWithOutput("docker", "build", "-t", image.name, "-f", dockerfile, context))
+	return errors.Trace(run.InteractiveWithOutput("docker", sh...))
 }
 
 func (image *ImageManager) LastBuiltID() (string, error) {
-	version, err := run.InteractiveCaptureOutput("docker", "image", "inspect", image.name, "-f", "{{.Id}}")
+	version, err := run.InteractiveCaptureOutput("docker", "image", "inspect", image.local(), "-f", "{{.Id}}")
 	if err != nil {
 		return "", errors.Trace(err)
 	}
@@ -62,6 +220,166 @@ func (image *ImageManager) LastBuiltID() (string, error) {
 	return strings.Split(version, ":")[1][:12], nil
 }
 
+// Exists checks if there is a local copy of the image, downloaded from the
+// main repository or any of the mirrors.
+func (image *ImageManager) Exists() (bool, error) {
+	for _, ref := range image.references() {
+		exists, err := refExists(ref)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// LocalReference returns the reference to run the image. It prefers the main
+// repository but it will use the copy of a mirror if that is the only local one.
+func (image *ImageManager) LocalReference() (string, error) {
+	if len(image.mirrors) == 0 {
+		return image.String(), nil
+	}
+
+	for _, ref := range image.references() {
+		exists, err := refExists(ref)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if exists {
+			return ref, nil
+		}
+	}
+
+	return image.String(), nil
+}
+
+func (image *ImageManager) references() []string {
+	refs := []string{image.String()}
+	for _, mirror := range image.mirrors {
+		refs = append(refs, image.reference(path.Join(mirror, image.Base())))
+	}
+	return refs
+}
+
+func refExists(ref string) (bool, error) {
+	exists, err := inspectExists("image", "inspect", ref)
+	return exists, errors.Trace(err)
+}
+
+func (image *ImageManager) Inspect() (*ImageInfo, error) {
+	ref, err := image.LocalReference()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	output, err := exec.Command("docker", "image", "inspect", ref).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot inspect image: %s", image)
+	}
+
+	var infos []*ImageInfo
+	if err := json.Unmarshal(output, &infos); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(infos) != 1 {
+		return nil, errors.Errorf("unexpected inspect output for image: %s", image)
+	}
+
+	return infos[0], nil
+}
+
+// RepoDigest returns the digest the registry assigned to the local copy of the image.
+func (image *ImageManager) RepoDigest() (string, error) {
+	info, err := image.Inspect()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	// Digests are the same in every mirror because they depend only on the content.
+	names := map[string]bool{image.name: true}
+	for _, mirror := range image.mirrors {
+		names[path.Join(mirror, image.Base())] = true
+	}
+	for _, repoDigest := range info.RepoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+		if len(parts) == 2 && names[parts[0]] {
+			return parts[1], nil
+		}
+	}
+
+	return "", errors.Errorf("image has no digest from the registry: %s", image)
+}
+
+// Base returns the name of the image without the repository.
+func (image *ImageManager) Base() string {
+	return path.Base(image.name)
+}
+
+func (image *ImageManager) Tag() string {
+	return image.tag
+}
+
+// RequireLocal fails listing the images that are not downloaded. It should be used
+// before running containers in offline mode to avoid implicit pulls.
+func RequireLocal(images ...*ImageManager) error {
+	var missing []string
+	for _, image := range images {
+		exists, err := image.Exists()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			missing = append(missing, image.String())
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("offline mode: images not downloaded: %s. Run `actools pull` with network access to prefetch them", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (image *ImageManager) Pinned() bool {
+	return image.digest != ""
+}
+
+// WarnMismatch alerts the user when the local copy does not match the pinned
+// digest. Docker will download the correct version before running it.
+func (image *ImageManager) WarnMismatch() error {
+	if !image.Pinned() || config.Offline() {
+		return nil
+	}
+
+	exists, err := image.Exists()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		log.WithFields(log.Fields{
+			"image":  image.name,
+			"digest": image.digest,
+		}).Warning("Local image does not match actools.lock. Run `actools pull` to download the pinned version.")
+	}
+
+	return nil
+}
+
 func (image *ImageManager) String() string {
-	return fmt.Sprintf("%s:latest", image.name)
+	return image.reference(image.name)
+}
+
+// local returns the name and tag the image is built with, ignoring the digest.
+func (image *ImageManager) local() string {
+	return fmt.Sprintf("%s:%s", image.name, image.tag)
+}
+
+func (image *ImageManager) reference(name string) string {
+	if image.digest != "" {
+		return fmt.Sprintf("%s@%s", name, image.digest)
+	}
+
+	return fmt.Sprintf("%s:%s", name, image.tag)
 }
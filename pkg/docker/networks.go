@@ -16,16 +16,8 @@ func Network(name string) *NetworkManager {
 }
 
 func (network *NetworkManager) Exists() (bool, error) {
-	cmd := exec.Command("docker", "network", "inspect", network.name)
-	if err := cmd.Run(); err != nil {
-		if !cmd.ProcessState.Success() {
-			return false, nil
-		}
-
-		return false, errors.Trace(err)
-	}
-
-	return true, nil
+	exists, err := inspectExists("network", "inspect", network.name)
+	return exists, errors.Trace(err)
 }
 
 func (network *NetworkManager) Create() error {
@@ -0,0 +1,332 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/run"
+)
+
+// DefaultPlatform is the only platform of the images that do not declare
+// other ones.
+const DefaultPlatform = "linux/amd64"
+
+var (
+	daemonPlatformOnce sync.Once
+	daemonPlatform     string
+	daemonPlatformErr  error
+)
+
+// DaemonPlatform returns the native platform of the Docker daemon, for example
+// linux/arm64 in Apple Silicon machines.
+func DaemonPlatform() (string, error) {
+	daemonPlatformOnce.Do(func() {
+		output, err := exec.Command("docker", "version", "-f", "{{.Server.Os}}/{{.Server.Arch}}").Output()
+		if err != nil {
+			daemonPlatformErr = errors.Wrapf(err, "cannot read the platform of the docker daemon")
+			return
+		}
+		daemonPlatform = strings.TrimSpace(string(output))
+	})
+	return daemonPlatform, daemonPlatformErr
+}
+
+// foreignDaemon returns true if the machine does not run the default platform
+// natively, so some images may only be available to run emulated.
+func foreignDaemon() bool {
+	native, err := DaemonPlatform()
+	return err == nil && !samePlatform(native, DefaultPlatform)
+}
+
+// logDefaultPlatform explains the second download of an image that may have
+// been published without the native platform of the machine. Docker refuses to
+// select another platform of a manifest list by itself.
+func logDefaultPlatform(ref string) {
+	log.WithFields(log.Fields{
+		"image":    ref,
+		"platform": DefaultPlatform,
+	}).Info("Cannot download the image for this machine, trying the default platform to run it emulated")
+}
+
+// Platform returns the platform of the local copy of the image.
+func (info *ImageInfo) Platform() string {
+	if info.Variant != "" {
+		return fmt.Sprintf("%s/%s/%s", info.Os, info.Architecture, info.Variant)
+	}
+	return fmt.Sprintf("%s/%s", info.Os, info.Architecture)
+}
+
+// emulatedImage is the platform of an image downloaded for a different
+// architecture than the machine.
+type emulatedImage struct {
+	Platform string `json:"platform"`
+	Native   string `json:"native"`
+}
+
+var emulatedMu sync.Mutex
+
+// emulatedFilename stores the images that run emulated. They are recorded when
+// pulling them to avoid inspecting the image and the daemon in every command.
+func emulatedFilename() string {
+	return filepath.Join(config.Home(), ".actools", "emulated-images.json")
+}
+
+func readEmulated() (map[string]emulatedImage, error) {
+	emulated := make(map[string]emulatedImage)
+	content, err := ioutil.ReadFile(emulatedFilename())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return emulated, nil
+		}
+		return nil, errors.Trace(err)
+	}
+
+	// A corrupted file is not important, the next pull will replace it.
+	if err := json.Unmarshal(content, &emulated); err != nil {
+		log.WithField("error", err.Error()).Debug("Cannot read the emulated images")
+		return make(map[string]emulatedImage), nil
+	}
+
+	return emulated, nil
+}
+
+// recordPlatform stores if the image just downloaded runs emulated in this
+// machine and alerts the user about it.
+func (image *ImageManager) recordPlatform() error {
+	info, err := image.Inspect()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	native, err := DaemonPlatform()
+	if err != nil {
+		// The daemon errors will be explained when running the container.
+		return nil
+	}
+
+	emulatedMu.Lock()
+	defer emulatedMu.Unlock()
+
+	emulated, err := readEmulated()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if samePlatform(info.Platform(), native) {
+		if _, ok := emulated[image.String()]; !ok {
+			return nil
+		}
+		delete(emulated, image.String())
+	} else {
+		emulated[image.String()] = emulatedImage{Platform: info.Platform(), Native: native}
+	}
+
+	content, err := json.Marshal(emulated)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(emulatedFilename()), 0700); err != nil {
+		return errors.Trace(err)
+	}
+	if err := ioutil.WriteFile(emulatedFilename(), content, 0600); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(image.WarnEmulated())
+}
+
+// WarnEmulated alerts the user when the local copy of the image was downloaded
+// for a different architecture than the machine and will run emulated. It only
+// reads the platform recorded when pulling the image.
+func (image *ImageManager) WarnEmulated() error {
+	emulated, err := readEmulated()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if record, ok := emulated[image.String()]; ok {
+		log.WithFields(log.Fields{
+			"image":           image.String(),
+			"image-platform":  record.Platform,
+			"native-platform": record.Native,
+		}).Warning("The image is not available for this machine and will run emulated, noticeably slower.")
+	}
+
+	return nil
+}
+
+// samePlatform compares the OS and architecture. Variants of the same
+// architecture run natively.
+func samePlatform(a, b string) bool {
+	pa, pb := strings.Split(a, "/"), strings.Split(b, "/")
+	return len(pa) >= 2 && len(pb) >= 2 && pa[0] == pb[0] && pa[1] == pb[1]
+}
+
+// platformTag returns the tag of the image for a single platform before joining
+// all of them in a multi-platform manifest.
+func (image *ImageManager) platformTag(platform string) string {
+	return fmt.Sprintf("%s:%s-%s", image.name, image.tag, strings.Replace(platform, "/", "-", -1))
+}
+
+// BuildMultiPlatform builds the image for every platform with buildx and
+// publishes them as a multi-platform manifest. The platforms receive their own
+// options on top of the common ones. The context hash of all of them is stored
+// as an annotation of the manifest to skip the tags that did not change.
+func (image *ImageManager) BuildMultiPlatform(context, dockerfile string, platforms map[string][]BuildOption, options ...BuildOption) error {
+	if dockerfile == "" {
+		dockerfile = path.Join(context, "Dockerfile")
+	}
+
+	names := make([]string, 0, len(platforms))
+	for platform := range platforms {
+		names = append(names, platform)
+	}
+	sort.Strings(names)
+
+	common := new(buildConfig)
+	for _, option := range options {
+		option(common)
+	}
+
+	builds := make(map[string]*buildConfig)
+	h := sha256.New()
+	for _, platform := range names {
+		build := &buildConfig{platform: platform}
+		for _, option := range options {
+			option(build)
+		}
+		for _, option := range platforms[platform] {
+			option(build)
+		}
+		builds[platform] = build
+
+		hash, err := contextHash(context, dockerfile, build)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		fmt.Fprintf(h, "%s %s\n", platform, hash)
+	}
+	hash := fmt.Sprintf("%x", h.Sum(nil))
+
+	if !common.force && publishedHash(image.local()) == hash {
+		log.WithField("name", image.local()).Info("Image up to date, skipping build")
+		return nil
+	}
+
+	// A single platform is published as a plain image instead of a manifest list.
+	// Docker refuses to pull a list without the native platform of the machine,
+	// but it pulls a plain image of other architecture to run it emulated.
+	if len(names) == 1 {
+		return errors.Trace(image.buildSinglePlatform(context, dockerfile, builds[names[0]], hash))
+	}
+
+	var refs []string
+	for _, platform := range names {
+		ref, err := image.buildPlatform(context, dockerfile, builds[platform])
+		if err != nil {
+			return errors.Wrapf(err, "platform %s", platform)
+		}
+		refs = append(refs, ref)
+	}
+
+	return errors.Trace(image.createManifest(hash, refs))
+}
+
+// buildPlatform builds the image for a single platform with buildx and pushes
+// it to the registry, because buildx cannot load foreign images in the local
+// daemon. It returns the reference of the pushed image.
+func (image *ImageManager) buildPlatform(context, dockerfile string, build *buildConfig) (string, error) {
+	ref := image.platformTag(build.platform)
+	log.WithFields(log.Fields{
+		"context":  context,
+		"name":     ref,
+		"platform": build.platform,
+	}).Info("Build image")
+
+	sh := []string{"buildx", "build", "--platform", build.platform, "--push", "-t", ref, "-f", dockerfile}
+	sh = append(sh, build.flags()...)
+	sh = append(sh, context)
+
+	if err := image.runBuild(build, ref, sh); err != nil {
+		return "", errors.Trace(err)
+	}
+	return ref, nil
+}
+
+// buildSinglePlatform builds the image for its only platform with buildx and
+// pushes it directly with the tag of the image, annotated with the context hash.
+// Provenance attestations are disabled because buildx would publish them inside
+// a manifest list.
+func (image *ImageManager) buildSinglePlatform(context, dockerfile string, build *buildConfig, hash string) error {
+	log.WithFields(log.Fields{
+		"context":  context,
+		"name":     image.local(),
+		"platform": build.platform,
+	}).Info("Build image")
+
+	sh := []string{
+		"buildx", "build",
+		"--platform", build.platform,
+		"--provenance=false",
+		"--output", fmt.Sprintf("type=image,name=%s,push=true,oci-mediatypes=true", image.local()),
+		"--annotation", fmt.Sprintf("manifest:%s=%s", ContextHashLabel, hash),
+		"-f", dockerfile,
+	}
+	sh = append(sh, build.flags()...)
+	sh = append(sh, context)
+
+	return errors.Trace(image.runBuild(build, image.local(), sh))
+}
+
+// createManifest publishes the tag of the image as a multi-platform manifest
+// with the images of each platform, annotated with their context hash.
+func (image *ImageManager) createManifest(hash string, refs []string) error {
+	log.WithFields(log.Fields{
+		"name":      image.local(),
+		"platforms": len(refs),
+	}).Info("Create multi-platform manifest")
+
+	sh := []string{"buildx", "imagetools", "create", "-t", image.local(), "--annotation", fmt.Sprintf("index:%s=%s", ContextHashLabel, hash)}
+	sh = append(sh, refs...)
+	return errors.Trace(run.InteractiveWithOutput("docker", sh...))
+}
+
+// CopyManifest publishes the multi-platform manifest of the image with another
+// tag of the same repository.
+func (image *ImageManager) CopyManifest(tag string) error {
+	ref := fmt.Sprintf("%s:%s", image.name, tag)
+	log.WithFields(log.Fields{
+		"name": image.local(),
+		"tag":  tag,
+	}).Info("Copy multi-platform manifest")
+
+	return errors.Trace(run.InteractiveWithOutput("docker", "buildx", "imagetools", "create", "-t", ref, image.local()))
+}
+
+// publishedHash returns the context hash annotated in the manifest of the
+// registry or an empty string if the tag does not exist or was not published by
+// actools. Both manifest lists and plain images store it in the same field.
+func publishedHash(ref string) string {
+	output, err := exec.Command("docker", "buildx", "imagetools", "inspect", "--raw", ref).Output()
+	if err != nil {
+		return ""
+	}
+	var index struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(output, &index); err != nil {
+		return ""
+	}
+	return index.Annotations[ContextHashLabel]
+}
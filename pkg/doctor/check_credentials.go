@@ -0,0 +1,50 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+)
+
+func checkSSHAgent() *Result {
+	socket := config.SSHAgentSocket()
+	if socket == "" {
+		return warn("no SSH_AUTH_SOCK defined in the environment", "Start an ssh-agent and add your keys: eval $(ssh-agent) && ssh-add")
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return warn("SSH_AUTH_SOCK points to a missing socket: "+socket, "Restart your session or the ssh-agent")
+	}
+
+	return pass("ssh-agent available at " + socket)
+}
+
+func checkGcloudConfig() *Result {
+	path := filepath.Join(config.Home(), ".config", "gcloud")
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return warn("gcloud is not configured", "Log in with: actools gcloud auth login")
+		}
+		return warn("cannot read the gcloud configuration: "+err.Error(), "")
+	}
+
+	return pass("gcloud configured in " + path)
+}
+
+func checkNetrcPermissions() *Result {
+	path := filepath.Join(config.Home(), ".netrc")
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pass("no ~/.netrc file")
+		}
+		return warn("cannot read ~/.netrc: "+err.Error(), "")
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return warn(fmt.Sprintf("~/.netrc is readable by other users (%s)", info.Mode().Perm()), "Restrict the permissions: chmod 600 ~/.netrc")
+	}
+
+	return pass("~/.netrc only readable by the owner")
+}
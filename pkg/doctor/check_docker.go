@@ -0,0 +1,57 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+	"github.com/altipla-consulting/actools/pkg/docker"
+)
+
+func checkDockerDaemon() *Result {
+	if err := docker.Ping(); err != nil {
+		if rerr, ok := err.(*docker.RuntimeError); ok {
+			return fail(rerr.Explanation, rerr.Fix)
+		}
+		return fail(err.Error(), "Check the docker installation running: docker info")
+	}
+
+	output, err := exec.Command("docker", "version", "--format", "{{.Server.Version}}").Output()
+	if err != nil {
+		return pass("docker daemon running")
+	}
+
+	return pass("docker daemon running version " + strings.TrimSpace(string(output)))
+}
+
+func checkDockerGroup() *Result {
+	// Other systems run docker inside a virtual machine without groups.
+	if !config.Linux() {
+		return pass("not needed outside Linux")
+	}
+	if os.Getuid() == 0 {
+		return pass("running as root")
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return warn("cannot read the current user: "+err.Error(), "")
+	}
+	group, err := user.LookupGroup("docker")
+	if err != nil {
+		return warn("docker group does not exist", "Create it and add your user: sudo groupadd docker && sudo usermod -aG docker $USER")
+	}
+	groups, err := current.GroupIds()
+	if err != nil {
+		return warn("cannot read the groups of the current user: "+err.Error(), "")
+	}
+	for _, gid := range groups {
+		if gid == group.Gid {
+			return pass("user " + current.Username + " is in the docker group")
+		}
+	}
+
+	return warn("user "+current.Username+" is not in the docker group", "Add your user to the group: sudo usermod -aG docker $USER, then log in again")
+}
@@ -0,0 +1,58 @@
+package doctor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/altipla-consulting/actools/pkg/containers"
+)
+
+const staleImageAge = 60 * 24 * time.Hour
+
+func checkStaleImages() *Result {
+	names := containers.ProjectImages()
+	if len(names) == 0 {
+		return pass("the project does not reference catalog images")
+	}
+
+	var missing, stale []string
+	for _, name := range names {
+		container, err := containers.FindImage(name)
+		if err != nil {
+			return warn(err.Error(), "")
+		}
+		image, err := container.DockerImage()
+		if err != nil {
+			return warn(err.Error(), "Fix the versions section of actools.yml")
+		}
+
+		exists, err := image.Exists()
+		if err != nil {
+			return warn("cannot inspect the images: "+err.Error(), "Fix the docker-daemon check first")
+		}
+		if !exists {
+			missing = append(missing, image.String())
+			continue
+		}
+
+		info, err := image.Inspect()
+		if err != nil {
+			return warn("cannot inspect the images: "+err.Error(), "Fix the docker-daemon check first")
+		}
+
+		// Pinned images are expected to remain in the same version.
+		if !image.Pinned() && time.Since(info.Created) > staleImageAge {
+			stale = append(stale, fmt.Sprintf("%s (%d days)", image.String(), int(time.Since(info.Created).Hours()/24)))
+		}
+	}
+
+	switch {
+	case len(missing) > 0:
+		return warn("images not downloaded: "+strings.Join(missing, ", "), "Download them with: actools pull")
+	case len(stale) > 0:
+		return warn("old images: "+strings.Join(stale, ", "), "Update them with: actools pull")
+	}
+
+	return pass(fmt.Sprintf("%d project images are up to date", len(names)))
+}
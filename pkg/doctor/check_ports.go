@@ -0,0 +1,137 @@
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+)
+
+func checkPorts() *Result {
+	ports := make(map[string]bool)
+	for _, service := range config.Settings.Services {
+		for _, port := range service.Ports {
+			ports[port] = true
+		}
+	}
+	for _, tool := range config.Settings.Tools {
+		for _, port := range tool.Ports {
+			ports[port] = true
+		}
+	}
+
+	var addresses []hostAddress
+	for port := range ports {
+		parsed, err := hostAddresses(port)
+		if err != nil {
+			return fail(fmt.Sprintf("invalid port %q: %s", port, err), "Check the ports of actools.yml")
+		}
+		addresses = append(addresses, parsed...)
+	}
+	if len(addresses) == 0 {
+		return pass("the project does not publish fixed ports")
+	}
+
+	var busy []string
+	for _, address := range addresses {
+		if !address.available() {
+			busy = append(busy, address.String())
+		}
+	}
+	if len(busy) > 0 {
+		sort.Strings(busy)
+		return warn("ports already in use: "+strings.Join(busy, ", "), "Stop the project containers or find the process with: sudo lsof -i :<port>")
+	}
+
+	return pass(fmt.Sprintf("%d ports available", len(addresses)))
+}
+
+type hostAddress struct {
+	network string
+	address string
+}
+
+func (address hostAddress) available() bool {
+	if address.network == "udp" {
+		conn, err := net.ListenPacket("udp", address.address)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	ln, err := net.Listen("tcp", address.address)
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+func (address hostAddress) String() string {
+	if address.network == "udp" {
+		return address.address + "/udp"
+	}
+	return address.address
+}
+
+// hostAddresses extracts the addresses of the host from the docker port syntax:
+// [[ip:]host:]container[/protocol]. The host and container ports can be ranges.
+// Ports without a fixed host side are published by docker in a random port of
+// the host and do not need to be checked.
+func hostAddresses(port string) ([]hostAddress, error) {
+	network := "tcp"
+	if parts := strings.SplitN(port, "/", 2); len(parts) == 2 {
+		port, network = parts[0], parts[1]
+	}
+
+	sep := strings.LastIndex(port, ":")
+	if sep == -1 {
+		return nil, nil
+	}
+	host := port[:sep]
+
+	var ip string
+	if sep := strings.LastIndex(host, ":"); sep != -1 {
+		ip, host = host[:sep], host[sep+1:]
+		ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+	}
+	if host == "" {
+		return nil, nil
+	}
+
+	start, end, err := portRange(host)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []hostAddress
+	for p := start; p <= end; p++ {
+		addresses = append(addresses, hostAddress{
+			network: network,
+			address: net.JoinHostPort(ip, strconv.Itoa(p)),
+		})
+	}
+	return addresses, nil
+}
+
+func portRange(value string) (int, int, error) {
+	parts := strings.SplitN(value, "-", 2)
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid host port: %s", value)
+	}
+	end := start
+	if len(parts) == 2 {
+		end, err = strconv.Atoi(parts[1])
+		if err != nil || end < start {
+			return 0, 0, errors.Errorf("invalid host port range: %s", value)
+		}
+	}
+	return start, end, nil
+}
@@ -0,0 +1,48 @@
+package doctor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHostAddresses(t *testing.T) {
+	tests := []struct {
+		port string
+		want []string
+	}{
+		{"8080", nil},
+		{"8080/udp", nil},
+		{"127.0.0.1::80", nil},
+		{"8000-8002", nil},
+		{"8080:80", []string{":8080"}},
+		{"8080:80/tcp", []string{":8080"}},
+		{"5353:53/udp", []string{":5353/udp"}},
+		{"127.0.0.1:8080:80", []string{"127.0.0.1:8080"}},
+		{"[::1]:8080:80", []string{"[::1]:8080"}},
+		{"8000-8002:8000-8002", []string{":8000", ":8001", ":8002"}},
+		{"127.0.0.1:9000-9001:80", []string{"127.0.0.1:9000", "127.0.0.1:9001"}},
+	}
+	for _, test := range tests {
+		t.Run(test.port, func(t *testing.T) {
+			addresses, err := hostAddresses(test.port)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, address := range addresses {
+				got = append(got, address.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("hostAddresses(%q) = %v, want %v", test.port, got, test.want)
+			}
+		})
+	}
+}
+
+func TestHostAddressesInvalid(t *testing.T) {
+	for _, port := range []string{"foo:80", "8010-8000:80", "8000-foo:80"} {
+		if _, err := hostAddresses(port); err == nil {
+			t.Errorf("hostAddresses(%q) expected error", port)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package doctor
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+
+	// Hint explains how to fix the problem if the check does not pass.
+	Hint string `json:"hint,omitempty"`
+}
+
+// Check is a single diagnostic of the environment. Checks should never fail,
+// any problem running them is reported in the result instead.
+type Check struct {
+	Name string
+	Run  func() *Result
+}
+
+var checks = []Check{
+	{Name: "docker-daemon", Run: checkDockerDaemon},
+	{Name: "docker-group", Run: checkDockerGroup},
+	{Name: "ssh-agent", Run: checkSSHAgent},
+	{Name: "gcloud-config", Run: checkGcloudConfig},
+	{Name: "netrc-permissions", Run: checkNetrcPermissions},
+	{Name: "stale-images", Run: checkStaleImages},
+	{Name: "ports", Run: checkPorts},
+}
+
+// Register adds a new check to the list that Run will execute.
+func Register(check Check) {
+	checks = append(checks, check)
+}
+
+// Run executes all the registered checks in order.
+func Run() []*Result {
+	var results []*Result
+	for _, check := range checks {
+		result := check.Run()
+		result.Check = check.Name
+		results = append(results, result)
+	}
+	return results
+}
+
+func pass(message string) *Result {
+	return &Result{Status: StatusPass, Message: message}
+}
+
+func warn(message, hint string) *Result {
+	return &Result{Status: StatusWarn, Message: message, Hint: hint}
+}
+
+func fail(message, hint string) *Result {
+	return &Result{Status: StatusFail, Message: message, Hint: hint}
+}
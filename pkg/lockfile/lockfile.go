@@ -0,0 +1,86 @@
+package lockfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+)
+
+const Filename = "actools.lock"
+
+const header = "# Generated by `actools lock update`. DO NOT EDIT.\n\n"
+
+var Current = new(Lockfile)
+
+func init() {
+	content, err := ioutil.ReadFile(Filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return
+		}
+
+		log.Fatal(err)
+	}
+
+	if err := yaml.Unmarshal(content, &Current); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type Lockfile struct {
+	Images map[string]*Image `yaml:"images"`
+}
+
+type Image struct {
+	Tag    string `yaml:"tag"`
+	Digest string `yaml:"digest"`
+}
+
+// Digest returns the pinned digest of a catalog image or an empty string if
+// the project does not pin that version of the image.
+func (lock *Lockfile) Digest(name, tag string) string {
+	image, ok := lock.Images[name]
+	if !ok {
+		return ""
+	}
+
+	// Lockfiles written before the versions support only pinned the latest tag.
+	lockTag := image.Tag
+	if lockTag == "" {
+		lockTag = "latest"
+	}
+	if lockTag != tag {
+		log.WithFields(log.Fields{
+			"image":  name,
+			"locked": lockTag,
+			"wanted": tag,
+		}).Warning("actools.lock pins a different version of the image. Run `actools lock update` to pin the new one.")
+		return ""
+	}
+
+	return image.Digest
+}
+
+func (lock *Lockfile) Set(name, tag, digest string) {
+	if lock.Images == nil {
+		lock.Images = make(map[string]*Image)
+	}
+	lock.Images[name] = &Image{Tag: tag, Digest: digest}
+}
+
+func (lock *Lockfile) Save() error {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.Write(content)
+
+	return errors.Trace(ioutil.WriteFile(Filename, buf.Bytes(), 0644))
+}
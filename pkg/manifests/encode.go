@@ -0,0 +1,54 @@
+package manifests
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Encode writes the objects as a multi-document YAML stream or as JSON. In JSON
+// several objects are wrapped in a List like kubectl does.
+func Encode(w io.Writer, format string, objects []*Object) error {
+	switch format {
+	case FormatYAML:
+		for i, object := range objects {
+			if i > 0 {
+				if _, err := io.WriteString(w, "---\n"); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			content, err := yaml.Marshal(object)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if _, err := w.Write(content); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+
+	case FormatJSON:
+		var value interface{} = objects
+		if len(objects) == 1 {
+			value = objects[0]
+		} else {
+			value = map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "List",
+				"items":      objects,
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return errors.Trace(encoder.Encode(value))
+	}
+
+	return errors.Errorf("unknown output format: %s", format)
+}
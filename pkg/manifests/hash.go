@@ -0,0 +1,44 @@
+package manifests
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+// HashSuffix computes the same suffix kustomize appends to the name of the
+// generated ConfigMaps and Secrets, so the name changes with the content.
+func (object *Object) HashSuffix() (string, error) {
+	content := map[string]interface{}{
+		"kind": object.Kind,
+		"name": object.Metadata.Name,
+		"data": object.Data,
+	}
+	if object.Type != "" {
+		content["type"] = object.Type
+	}
+	if len(object.BinaryData) > 0 {
+		content["binaryData"] = object.BinaryData
+	}
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	// Kustomize replaces some characters to avoid generating bad words.
+	hex := fmt.Sprintf("%x", sha256.Sum256(encoded))[:10]
+	return strings.NewReplacer("0", "g", "1", "h", "3", "k", "a", "m", "e", "t").Replace(hex), nil
+}
+
+// AddHashSuffix renames the object appending the hash of its content.
+func (object *Object) AddHashSuffix() error {
+	suffix, err := object.HashSuffix()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	object.Metadata.Name = fmt.Sprintf("%s-%s", object.Metadata.Name, suffix)
+	return nil
+}
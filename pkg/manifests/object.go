@@ -0,0 +1,49 @@
+package manifests
+
+import (
+	"encoding/base64"
+	"unicode/utf8"
+)
+
+// Object is the YAML model shared by the ConfigMaps and Secrets we generate.
+type Object struct {
+	APIVersion string            `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string            `yaml:"kind" json:"kind"`
+	Metadata   Metadata          `yaml:"metadata" json:"metadata"`
+	Type       string            `yaml:"type,omitempty" json:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty" json:"data,omitempty"`
+	BinaryData map[string]string `yaml:"binaryData,omitempty" json:"binaryData,omitempty"`
+}
+
+type Metadata struct {
+	Name        string            `yaml:"name" json:"name"`
+	Namespace   string            `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// NewConfigMap builds a ConfigMap with the content of the sources. Values that
+// are not valid UTF-8 are stored encoded in base64 in binaryData.
+func NewConfigMap(metadata Metadata, values map[string][]byte) *Object {
+	cm := &Object{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   metadata,
+	}
+	for key, value := range values {
+		if utf8.Valid(value) {
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			cm.Data[key] = string(value)
+			continue
+		}
+
+		if cm.BinaryData == nil {
+			cm.BinaryData = make(map[string]string)
+		}
+		cm.BinaryData[key] = base64.StdEncoding.EncodeToString(value)
+	}
+
+	return cm
+}
@@ -0,0 +1,81 @@
+package manifests
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+)
+
+// configMapReferences are the keys of the objects that reference a ConfigMap
+// by name in a pod template: envFrom, env valueFrom and volumes.
+var configMapReferences = map[string]bool{
+	"configMapRef":    true,
+	"configMapKeyRef": true,
+	"configMap":       true,
+}
+
+// PatchConfigMapReferences rewrites the references to the ConfigMap in a
+// multi-document YAML stream to use the new name. References to a previous
+// hashed name of the same ConfigMap are updated too. It returns the number of
+// replaced references. Comments of the original file are not preserved.
+func PatchConfigMapReferences(content []byte, name, newName string) ([]byte, int, error) {
+	// Only the characters that HashSuffix generates, to avoid confusing other
+	// ConfigMaps like app-production with a hashed version of app.
+	previous := regexp.MustCompile(`^` + regexp.QuoteMeta(name) + `(-[2456789bcdfghkmt]{10})?$`)
+
+	var out bytes.Buffer
+	var replaced int
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for i := 0; ; i++ {
+		var doc yaml.MapSlice
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, errors.Trace(err)
+		}
+		if doc == nil {
+			continue
+		}
+
+		replaced += patchValue(doc, false, previous, newName)
+
+		encoded, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, 0, errors.Trace(err)
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(encoded)
+	}
+
+	return out.Bytes(), replaced, nil
+}
+
+func patchValue(value interface{}, reference bool, previous *regexp.Regexp, newName string) int {
+	var replaced int
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for i, item := range v {
+			key, _ := item.Key.(string)
+			if reference && key == "name" {
+				if name, ok := item.Value.(string); ok && previous.MatchString(name) {
+					v[i].Value = newName
+					replaced++
+				}
+				continue
+			}
+			replaced += patchValue(item.Value, configMapReferences[key], previous, newName)
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			replaced += patchValue(item, false, previous, newName)
+		}
+	}
+	return replaced
+}
@@ -0,0 +1,82 @@
+package manifests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        envFrom:
+        - configMapRef:
+            name: %s
+      volumes:
+      - name: config
+        configMap:
+          name: %s
+`
+
+func TestPatchConfigMapReferences(t *testing.T) {
+	cm := NewConfigMap(Metadata{Name: "app"}, map[string][]byte{"foo": []byte("bar")})
+	if err := cm.AddHashSuffix(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		current  string
+		replaced bool
+	}{
+		{"unhashed name", "app", true},
+		{"previous hash", cm.Metadata.Name, true},
+		{"kustomize alphabet", "app-2456789bcd", true},
+		{"other configmap with suffix", "app-production", false},
+		{"other configmap with ten letters", "app-staging123", false},
+		{"hex characters outside of the alphabet", "app-0123456789", false},
+		{"different prefix", "other-2456789bcd", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := []byte(fmt.Sprintf(deployment, test.current, test.current))
+			patched, replaced, err := PatchConfigMapReferences(content, "app", "app-fgh2456789")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := 0
+			if test.replaced {
+				want = 2
+			}
+			if replaced != want {
+				t.Errorf("replaced %d references, want %d", replaced, want)
+			}
+			if test.replaced != strings.Contains(string(patched), "name: app-fgh2456789") {
+				t.Errorf("unexpected patched content:\n%s", patched)
+			}
+			if !strings.Contains(string(patched), "- name: app\n") {
+				t.Errorf("the name of the container should not change:\n%s", patched)
+			}
+		})
+	}
+}
+
+func TestHashSuffixAlphabet(t *testing.T) {
+	for _, value := range []string{"", "foo", "bar", "baz", "qux"} {
+		cm := NewConfigMap(Metadata{Name: "app"}, map[string][]byte{"key": []byte(value)})
+		suffix, err := cm.HashSuffix()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(suffix) != 10 || strings.Trim(suffix, "2456789bcdfghkmt") != "" {
+			t.Errorf("suffix %q outside of the patched alphabet", suffix)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package manifests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"libs.altipla.consulting/errors"
+)
+
+const (
+	SecretTypeOpaque           = "Opaque"
+	SecretTypeTLS              = "kubernetes.io/tls"
+	SecretTypeDockerConfigJSON = "kubernetes.io/dockerconfigjson"
+)
+
+var requiredSecretKeys = map[string][]string{
+	SecretTypeOpaque:           nil,
+	SecretTypeTLS:              {"tls.crt", "tls.key"},
+	SecretTypeDockerConfigJSON: {".dockerconfigjson"},
+}
+
+// NewSecret builds a Secret of the type with the content of the sources encoded
+// in base64. It checks the keys each type requires.
+func NewSecret(metadata Metadata, secretType string, values map[string][]byte) (*Object, error) {
+	required, ok := requiredSecretKeys[secretType]
+	if !ok {
+		return nil, errors.Errorf("unknown secret type: %s", secretType)
+	}
+	for _, key := range required {
+		if _, ok := values[key]; !ok {
+			return nil, errors.Errorf("secrets of type %s require the key %q", secretType, key)
+		}
+	}
+	if secretType == SecretTypeDockerConfigJSON && !json.Valid(values[".dockerconfigjson"]) {
+		return nil, errors.Errorf("the key .dockerconfigjson is not valid JSON")
+	}
+
+	secret := &Object{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   metadata,
+		Type:       secretType,
+	}
+	for key, value := range values {
+		if secret.Data == nil {
+			secret.Data = make(map[string]string)
+		}
+		secret.Data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,204 @@
+package manifests
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"libs.altipla.consulting/errors"
+)
+
+var validKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// Sources lists the origins of the values of a manifest.
+type Sources struct {
+	// Files can be a path, a directory that will be read recursively or key=path
+	// to use a different key than the name of the file. The path - reads the
+	// standard input and always needs a key.
+	Files []string
+
+	// EnvFiles contain KEY=VALUE lines. The path - reads the standard input.
+	EnvFiles []string
+
+	// Literals are KEY=VALUE pairs.
+	Literals []string
+}
+
+func (sources Sources) Empty() bool {
+	return len(sources.Files) == 0 && len(sources.EnvFiles) == 0 && len(sources.Literals) == 0
+}
+
+// Load reads all the sources. Repeated keys are an error.
+func (sources Sources) Load() (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	add := func(key string, value []byte, origin string) error {
+		if !validKey.MatchString(key) {
+			return errors.Errorf("invalid key %q from %s", key, origin)
+		}
+		if _, ok := values[key]; ok {
+			return errors.Errorf("duplicated key %q from %s", key, origin)
+		}
+		values[key] = value
+		return nil
+	}
+
+	for _, file := range sources.Files {
+		key, path := "", file
+		if idx := strings.Index(file, "="); idx != -1 {
+			key, path = file[:idx], file[idx+1:]
+		}
+
+		if path == "-" {
+			if key == "" {
+				return nil, errors.Errorf("the standard input requires a key: key=-")
+			}
+			content, err := readStdin()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if err := add(key, content, "stdin"); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if info.IsDir() {
+			if key != "" {
+				return nil, errors.Errorf("cannot rename the directory %s", path)
+			}
+			err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if !info.Mode().IsRegular() {
+					return nil
+				}
+				content, err := ioutil.ReadFile(path)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return errors.Trace(add(filepath.Base(path), content, path))
+			})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+
+		if key == "" {
+			key = filepath.Base(path)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err := add(key, content, path); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	for _, file := range sources.EnvFiles {
+		var content []byte
+		var err error
+		if file == "-" {
+			content, err = readStdin()
+		} else {
+			content, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		pairs, err := ParseEnv(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read env file %s", file)
+		}
+		for _, pair := range pairs {
+			if err := add(pair[0], []byte(pair[1]), file); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+
+	for _, literal := range sources.Literals {
+		key, value, err := splitPair(literal)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err := add(key, []byte(value), "literal"); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	return values, nil
+}
+
+var (
+	stdinOnce    sync.Once
+	stdinContent []byte
+	stdinErr     error
+)
+
+// readStdin reads the standard input only once even if several sources of the
+// same run use it.
+func readStdin() ([]byte, error) {
+	stdinOnce.Do(func() {
+		stdinContent, stdinErr = ioutil.ReadAll(os.Stdin)
+	})
+	return stdinContent, errors.Trace(stdinErr)
+}
+
+// ParseEnv reads KEY=VALUE lines ignoring empty lines and comments.
+func ParseEnv(content []byte) ([][2]string, error) {
+	var pairs [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := splitPair(line)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return pairs, nil
+}
+
+func splitPair(pair string) (string, string, error) {
+	parts := strings.SplitN(pair, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", errors.Errorf("invalid KEY=VALUE pair: %s", pair)
+	}
+	return parts[0], parts[1], nil
+}
+
+// ParseMap reads a list of key=value pairs like labels or annotations.
+func ParseMap(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	m := make(map[string]string)
+	for _, pair := range pairs {
+		key, value, err := splitPair(pair)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
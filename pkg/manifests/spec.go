@@ -0,0 +1,108 @@
+package manifests
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+)
+
+// ConfigMapSpec describes how to generate a ConfigMap from local sources.
+type ConfigMapSpec struct {
+	Name        string            `yaml:"-"`
+	Namespace   string            `yaml:"namespace"`
+	Files       []string          `yaml:"files"`
+	EnvFiles    []string          `yaml:"env-files"`
+	Literals    []string          `yaml:"literals"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+	HashSuffix  bool              `yaml:"hash-suffix"`
+
+	// Patch lists manifests whose references to the ConfigMap should be
+	// rewritten with the final name.
+	Patch []string `yaml:"patch"`
+}
+
+// LoadSpecs reads a file with the ConfigMaps indexed by name. Relative paths
+// are resolved from the directory of the file.
+func LoadSpecs(filename string) ([]*ConfigMapSpec, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	indexed := make(map[string]*ConfigMapSpec)
+	if err := yaml.UnmarshalStrict(content, &indexed); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", filename)
+	}
+
+	dir := filepath.Dir(filename)
+	var specs []*ConfigMapSpec
+	for name, spec := range indexed {
+		if spec == nil {
+			spec = new(ConfigMapSpec)
+		}
+		spec.Name = name
+		for i, file := range spec.Files {
+			spec.Files[i] = resolveSource(dir, file)
+		}
+		for i, file := range spec.EnvFiles {
+			spec.EnvFiles[i] = resolvePath(dir, file)
+		}
+		for i, file := range spec.Patch {
+			spec.Patch[i] = resolvePath(dir, file)
+		}
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+
+	return specs, nil
+}
+
+func resolveSource(dir, source string) string {
+	if idx := strings.Index(source, "="); idx != -1 {
+		return source[:idx+1] + resolvePath(dir, source[idx+1:])
+	}
+	return resolvePath(dir, source)
+}
+
+func resolvePath(dir, path string) string {
+	if path == "-" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Build reads the sources and generates the ConfigMap.
+func (spec *ConfigMapSpec) Build() (*Object, error) {
+	sources := Sources{
+		Files:    spec.Files,
+		EnvFiles: spec.EnvFiles,
+		Literals: spec.Literals,
+	}
+	if sources.Empty() {
+		return nil, errors.Errorf("configmap %s has no sources", spec.Name)
+	}
+	values, err := sources.Load()
+	if err != nil {
+		return nil, errors.Wrapf(err, "configmap %s", spec.Name)
+	}
+
+	cm := NewConfigMap(Metadata{
+		Name:        spec.Name,
+		Namespace:   spec.Namespace,
+		Labels:      spec.Labels,
+		Annotations: spec.Annotations,
+	}, values)
+	if spec.HashSuffix {
+		if err := cm.AddHashSuffix(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	return cm, nil
+}
@@ -0,0 +1,161 @@
+package scaffold
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/containers"
+)
+
+// skipDirs are never inspected because they contain dependencies or generated
+// files instead of the sources of the project.
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// Detection is what the inspection of the tree found.
+type Detection struct {
+	// GoVersion and NodeVersion are the versions the project declares, empty
+	// if it does not use the language or does not declare one.
+	Go          bool
+	GoVersion   string
+	Node        bool
+	NodeVersion string
+	PHP         bool
+	Protos      bool
+
+	// AppEngine lists the directories with an app.yaml file.
+	AppEngine []string
+
+	// Migrations lists the migrations directories.
+	Migrations []string
+}
+
+var (
+	goDirective  = regexp.MustCompile(`^go\s+(\d+\.\d+)`)
+	nodeVersion  = regexp.MustCompile(`(\d+)`)
+	migrationDir = regexp.MustCompile(`^(db[-_]?)?migrations?$`)
+)
+
+// Detect inspects the tree of the project from the root directory.
+func Detect(root string) (*Detection, error) {
+	detection := new(Detection)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		if info.IsDir() {
+			if rel != "." && (strings.HasPrefix(info.Name(), ".") || skipDirs[info.Name()]) {
+				return filepath.SkipDir
+			}
+			if migrationDir.MatchString(info.Name()) {
+				detection.Migrations = append(detection.Migrations, filepath.ToSlash(rel))
+			}
+			return nil
+		}
+
+		switch {
+		case info.Name() == "go.mod":
+			detection.Go = true
+			if rel == "go.mod" {
+				if detection.GoVersion, err = readGoVersion(path); err != nil {
+					return errors.Trace(err)
+				}
+			}
+
+		case info.Name() == "package.json":
+			detection.Node = true
+			if rel == "package.json" {
+				if detection.NodeVersion, err = readNodeVersion(path); err != nil {
+					return errors.Trace(err)
+				}
+			}
+
+		case info.Name() == "composer.json":
+			detection.PHP = true
+
+		case info.Name() == "app.yaml":
+			detection.AppEngine = append(detection.AppEngine, filepath.ToSlash(filepath.Dir(rel)))
+
+		case filepath.Ext(info.Name()) == ".proto":
+			detection.Protos = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	sort.Strings(detection.AppEngine)
+	sort.Strings(detection.Migrations)
+
+	return detection, nil
+}
+
+func readGoVersion(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if match := goDirective.FindStringSubmatch(strings.TrimSpace(scanner.Text())); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", errors.Trace(scanner.Err())
+}
+
+func readNodeVersion(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var pkg struct {
+		Engines struct {
+			Node string `json:"node"`
+		} `json:"engines"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return "", errors.Wrapf(err, "cannot parse %s", path)
+	}
+
+	// Only the major version matters to select the image.
+	return nodeVersion.FindString(pkg.Engines.Node), nil
+}
+
+// SupportedVersion returns the version if the catalog publishes it for the
+// image, or an empty string to use the latest one.
+func SupportedVersion(image, version string) string {
+	if version == "" {
+		return ""
+	}
+	container, err := containers.FindImage(image)
+	if err != nil {
+		return ""
+	}
+	for _, v := range container.Versions {
+		if v == version {
+			return version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package scaffold
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates the files of a project in a temporary directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  *Detection
+	}{
+		{
+			name:  "empty",
+			files: map[string]string{"README.md": "# Project\n"},
+			want:  &Detection{},
+		},
+		{
+			name:  "go version",
+			files: map[string]string{"go.mod": "module example.com/foo\n\ngo 1.21.4\n"},
+			want:  &Detection{Go: true, GoVersion: "1.21"},
+		},
+		{
+			name:  "go without version",
+			files: map[string]string{"go.mod": "module example.com/foo\n"},
+			want:  &Detection{Go: true},
+		},
+		{
+			name:  "nested go module does not declare the version",
+			files: map[string]string{"tools/go.mod": "module example.com/tools\n\ngo 1.20\n"},
+			want:  &Detection{Go: true},
+		},
+		{
+			name:  "node engines",
+			files: map[string]string{"package.json": `{"engines": {"node": ">=18.12"}}`},
+			want:  &Detection{Node: true, NodeVersion: "18"},
+		},
+		{
+			name:  "node without engines",
+			files: map[string]string{"package.json": `{"name": "foo"}`},
+			want:  &Detection{Node: true},
+		},
+		{
+			name:  "php and protos",
+			files: map[string]string{"composer.json": "{}", "protos/foo/foo.proto": "syntax = \"proto3\";\n"},
+			want:  &Detection{PHP: true, Protos: true},
+		},
+		{
+			name: "app engine and migrations",
+			files: map[string]string{
+				"app.yaml":                 "runtime: go121\n",
+				"backend/api/app.yaml":     "runtime: go121\n",
+				"db/migrations/001.sql":    "",
+				"db-migrations/002.sql":    "",
+				"services/migration/x.sql": "",
+			},
+			want: &Detection{
+				AppEngine:  []string{".", "backend/api"},
+				Migrations: []string{"db-migrations", "db/migrations", "services/migration"},
+			},
+		},
+		{
+			name: "skipped directories",
+			files: map[string]string{
+				"node_modules/foo/package.json": `{"engines": {"node": "16"}}`,
+				"vendor/foo/go.mod":             "module foo\n",
+				"dist/app.yaml":                 "",
+				"build/foo.proto":               "",
+				".git/migrations/foo":           "",
+				".github/composer.json":         "{}",
+			},
+			want: &Detection{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detection, err := Detect(writeTree(t, test.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(detection, test.want) {
+				t.Errorf("Detect = %+v, want %+v", detection, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectInvalidPackageJSON(t *testing.T) {
+	root := writeTree(t, map[string]string{"package.json": "{"})
+	if _, err := Detect(root); err == nil {
+		t.Error("expected error with an invalid package.json")
+	}
+}
+
+func TestSupportedVersion(t *testing.T) {
+	tests := []struct {
+		image, version, want string
+	}{
+		{"go", "1.21", "1.21"},
+		{"go", "1.12", ""},
+		{"go", "", ""},
+		{"node", "18", "18"},
+		{"node", "4", ""},
+		{"unknown", "1", ""},
+	}
+	for _, test := range tests {
+		if got := SupportedVersion(test.image, test.version); got != test.want {
+			t.Errorf("SupportedVersion(%q, %q) = %q, want %q", test.image, test.version, got, test.want)
+		}
+	}
+}
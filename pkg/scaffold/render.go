@@ -0,0 +1,176 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	SectionVersions = "versions"
+	SectionServices = "services"
+	SectionTools    = "tools"
+)
+
+var sectionComments = map[string]string{
+	SectionVersions: "Versions of the catalog images. The latest ones are used if not specified.",
+	SectionServices: "Containers that keep running in the background while developing.",
+	SectionTools:    "Commands that run inside a container of the catalog.",
+}
+
+// Item is a proposed entry of the actools.yml file.
+type Item struct {
+	Section string
+	Name    string
+	Comment string
+
+	// Value is the YAML of the entry without indentation. Multiple lines are
+	// nested below the name.
+	Value string
+}
+
+func (item *Item) String() string {
+	return fmt.Sprintf("%s %s (%s)", strings.TrimSuffix(item.Section, "s"), item.Name, strings.TrimSuffix(item.Comment, "."))
+}
+
+var invalidName = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Propose lists the entries for what was detected. Notes explain detections
+// that could not be translated automatically.
+func Propose(detection *Detection) (items []*Item, notes []string) {
+	if detection.Go {
+		if version := SupportedVersion("go", detection.GoVersion); version != "" {
+			items = append(items, &Item{SectionVersions, "go", "Declared in go.mod.", fmt.Sprintf("%q", version)})
+		} else if detection.GoVersion != "" {
+			notes = append(notes, fmt.Sprintf("go.mod declares Go %s, which is not published in the catalog; the latest image will be used", detection.GoVersion))
+		}
+	}
+	if detection.Node {
+		if version := SupportedVersion("node", detection.NodeVersion); version != "" {
+			items = append(items, &Item{SectionVersions, "node", "Declared in the engines of package.json.", fmt.Sprintf("%q", version)})
+		} else if detection.NodeVersion != "" {
+			notes = append(notes, fmt.Sprintf("package.json requires Node %s, which is not published in the catalog; the latest image will be used", detection.NodeVersion))
+		}
+	}
+
+	// The database service of the migrations is added later, keep its name free.
+	taken := map[string]bool{}
+	if len(detection.Migrations) > 0 {
+		taken["database"] = true
+	}
+	names := serviceNames(detection.AppEngine, taken)
+	for i, dir := range detection.AppEngine {
+		items = append(items, &Item{
+			Section: SectionServices,
+			Name:    names[i],
+			Comment: fmt.Sprintf("App Engine application in %s.", dirLabel(dir)),
+			Value:   fmt.Sprintf("type: dev-appengine\nworkdir: %s\nports:\n- %d:8080", dir, 8080+i),
+		})
+	}
+	if len(detection.Migrations) > 0 {
+		items = append(items, &Item{
+			Section: SectionServices,
+			Name:    "database",
+			Comment: fmt.Sprintf("Database for the migrations in %s.", dirLabel(detection.Migrations[0])),
+			Value:   "type: mysql\nports:\n- 3306:3306",
+		})
+		items = append(items, &Item{
+			Section: SectionTools,
+			Name:    "migrator",
+			Comment: "Applies the migrations to the database service.",
+			Value:   "container: migrator\ndeps:\n- database",
+		})
+	}
+
+	if detection.Go {
+		items = append(items, &Item{SectionTools, "go", "Found go.mod.", "container: go"})
+	}
+	if detection.Node {
+		items = append(items, &Item{SectionTools, "npm", "Found package.json.", "container: node"})
+	}
+	if detection.PHP {
+		items = append(items, &Item{SectionTools, "composer", "Found composer.json.", "container: php"})
+	}
+	if detection.Protos {
+		items = append(items, &Item{SectionTools, "protoc", "Found .proto files.", "container: protoc"})
+	}
+
+	return items, notes
+}
+
+// serviceNames names the services after the last directory of each one. If that
+// directory repeats the whole path is used instead, and names that still collide
+// with another one receive a numeric suffix.
+func serviceNames(dirs []string, taken map[string]bool) []string {
+	repeated := make(map[string]int)
+	for _, dir := range dirs {
+		repeated[serviceName(path.Base(dir))]++
+	}
+
+	var names []string
+	for _, dir := range dirs {
+		base := serviceName(path.Base(dir))
+		if repeated[base] > 1 {
+			base = serviceName(dir)
+		}
+		name := base
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+func serviceName(dir string) string {
+	name := strings.Trim(invalidName.ReplaceAllString(strings.ToLower(dir), "-"), "-")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+func dirLabel(dir string) string {
+	if dir == "." {
+		return "the root of the repository"
+	}
+	return "./" + dir
+}
+
+// Render writes the commented actools.yml file with the items.
+func Render(project string, items []*Item) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by `actools init`. Review it before committing the file.\n\n")
+	buf.WriteString("# Name of the project, it defaults to the directory of the repository.\n")
+	fmt.Fprintf(&buf, "project: %s\n", project)
+
+	for _, section := range []string{SectionVersions, SectionServices, SectionTools} {
+		var first = true
+		for _, item := range items {
+			if item.Section != section {
+				continue
+			}
+			if first {
+				fmt.Fprintf(&buf, "\n# %s\n%s:\n", sectionComments[section], section)
+				first = false
+			} else if section != SectionVersions {
+				buf.WriteString("\n")
+			}
+
+			fmt.Fprintf(&buf, "  # %s\n", item.Comment)
+			if !strings.Contains(item.Value, "\n") && section == SectionVersions {
+				fmt.Fprintf(&buf, "  %s: %s\n", item.Name, item.Value)
+				continue
+			}
+			fmt.Fprintf(&buf, "  %s:\n", item.Name)
+			for _, line := range strings.Split(item.Value, "\n") {
+				fmt.Fprintf(&buf, "    %s\n", line)
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,173 @@
+package scaffold
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+)
+
+func itemNames(items []*Item, section string) []string {
+	var names []string
+	for _, item := range items {
+		if item.Section == section {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
+
+func TestPropose(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection *Detection
+		versions  []string
+		services  []string
+		tools     []string
+		notes     int
+	}{
+		{
+			name:      "empty",
+			detection: &Detection{},
+		},
+		{
+			name:      "supported versions",
+			detection: &Detection{Go: true, GoVersion: "1.21", Node: true, NodeVersion: "18"},
+			versions:  []string{"go", "node"},
+			tools:     []string{"go", "npm"},
+		},
+		{
+			name:      "unsupported versions",
+			detection: &Detection{Go: true, GoVersion: "1.12", Node: true, NodeVersion: "4"},
+			tools:     []string{"go", "npm"},
+			notes:     2,
+		},
+		{
+			name:      "without versions",
+			detection: &Detection{Go: true, Node: true},
+			tools:     []string{"go", "npm"},
+		},
+		{
+			name:      "php and protos",
+			detection: &Detection{PHP: true, Protos: true},
+			tools:     []string{"composer", "protoc"},
+		},
+		{
+			name:      "migrations",
+			detection: &Detection{Migrations: []string{"db/migrations", "migrations"}},
+			services:  []string{"database"},
+			tools:     []string{"migrator"},
+		},
+		{
+			name:      "app engine",
+			detection: &Detection{AppEngine: []string{".", "backend/API_v2"}},
+			services:  []string{"app", "api-v2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, notes := Propose(test.detection)
+
+			if got := itemNames(items, SectionVersions); !reflect.DeepEqual(got, test.versions) {
+				t.Errorf("versions = %v, want %v", got, test.versions)
+			}
+			if got := itemNames(items, SectionServices); !reflect.DeepEqual(got, test.services) {
+				t.Errorf("services = %v, want %v", got, test.services)
+			}
+			if got := itemNames(items, SectionTools); !reflect.DeepEqual(got, test.tools) {
+				t.Errorf("tools = %v, want %v", got, test.tools)
+			}
+			if len(notes) != test.notes {
+				t.Errorf("notes = %q, want %d", notes, test.notes)
+			}
+		})
+	}
+}
+
+func TestProposeUniqueServiceNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		dirs       []string
+		migrations []string
+		want       []string
+	}{
+		{"root and app directory", []string{".", "app"}, nil, []string{"app", "app-2"}},
+		{"same last directory", []string{"a/api", "b/api", "web"}, nil, []string{"a-api", "b-api", "web"}},
+		{"same path after cleaning", []string{"a/my_api", "a/my-api"}, nil, []string{"a-my-api", "a-my-api-2"}},
+		{"database of the migrations", []string{"database"}, []string{"migrations"}, []string{"database-2", "database"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, _ := Propose(&Detection{AppEngine: test.dirs, Migrations: test.migrations})
+			if got := itemNames(items, SectionServices); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("services = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	detection := &Detection{
+		Go:          true,
+		GoVersion:   "1.21",
+		Node:        true,
+		NodeVersion: "18",
+		PHP:         true,
+		Protos:      true,
+		AppEngine:   []string{".", "a/api", "b/api"},
+		Migrations:  []string{"db/migrations"},
+	}
+	items, _ := Propose(detection)
+	content := Render("foo", items)
+
+	cnf := new(config.Config)
+	if err := yaml.UnmarshalStrict(content, cnf); err != nil {
+		t.Fatalf("cannot parse the rendered file: %s\n%s", err, content)
+	}
+
+	if cnf.Project != "foo" {
+		t.Errorf("project = %q, want foo", cnf.Project)
+	}
+	if !reflect.DeepEqual(cnf.Versions, map[string]string{"go": "1.21", "node": "18"}) {
+		t.Errorf("versions = %v", cnf.Versions)
+	}
+	if len(cnf.Services) != 4 {
+		t.Errorf("services = %v, want 4 of them", cnf.Services)
+	}
+	api := cnf.Services["b-api"]
+	if api == nil {
+		t.Fatalf("missing service b-api:\n%s", content)
+	}
+	if api.Type != "dev-appengine" || api.Workdir != "b/api" || !reflect.DeepEqual(api.Ports, []string{"8082:8080"}) {
+		t.Errorf("unexpected service b-api: %+v", api)
+	}
+	if db := cnf.Services["database"]; db == nil || db.Type != "mysql" {
+		t.Errorf("unexpected service database: %+v", db)
+	}
+	if len(cnf.Tools) != 5 {
+		t.Errorf("tools = %v, want 5 of them", cnf.Tools)
+	}
+	if migrator := cnf.Tools["migrator"]; migrator == nil || migrator.Container != "migrator" || !reflect.DeepEqual(migrator.Deps, []string{"database"}) {
+		t.Errorf("unexpected tool migrator: %+v", migrator)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	content := Render("foo", nil)
+
+	cnf := new(config.Config)
+	if err := yaml.UnmarshalStrict(content, cnf); err != nil {
+		t.Fatalf("cannot parse the rendered file: %s\n%s", err, content)
+	}
+	if cnf.Project != "foo" {
+		t.Errorf("project = %q, want foo", cnf.Project)
+	}
+	for _, section := range []string{SectionVersions, SectionServices, SectionTools} {
+		if strings.Contains(string(content), section+":") {
+			t.Errorf("empty section %s rendered:\n%s", section, content)
+		}
+	}
+}
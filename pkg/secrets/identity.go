@@ -0,0 +1,167 @@
+package secrets
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/terminal"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/config"
+)
+
+// Identity is the private key of the current user.
+type Identity struct {
+	Path      string
+	PublicKey ssh.PublicKey
+
+	private interface{}
+}
+
+// identityPaths returns the private keys we try in order. ACTOOLS_SECRETS_IDENTITY
+// can point to a specific one.
+func identityPaths() []string {
+	if path := os.Getenv("ACTOOLS_SECRETS_IDENTITY"); path != "" {
+		return []string{path}
+	}
+	return []string{
+		filepath.Join(config.Home(), ".ssh", "id_ed25519"),
+		filepath.Join(config.Home(), ".ssh", "id_rsa"),
+	}
+}
+
+var (
+	identitiesMu sync.Mutex
+
+	// identities keeps the keys already read to ask for each passphrase only
+	// once in the process.
+	identities = map[string]*Identity{}
+)
+
+// LoadIdentity reads the private key of the user that can decrypt the file. All
+// the available keys are tried in order and encrypted ones ask for the passphrase
+// in the terminal only if they are recipients of the file. Without a file the first
+// available key is returned.
+func LoadIdentity(file *File) (*Identity, error) {
+	var tried []string
+	var passphraseErr error
+	for _, path := range identityPaths() {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Trace(err)
+		}
+		tried = append(tried, path)
+
+		if file != nil {
+			if key := readPublicKey(path, content); key != nil && !file.hasRecipient(key) {
+				continue
+			}
+		}
+
+		identity, err := cachedIdentity(path, content)
+		if err != nil {
+			if errors.Is(err, ErrPassphraseRequired) {
+				passphraseErr = err
+				continue
+			}
+			return nil, errors.Trace(err)
+		}
+		if file != nil && !file.hasRecipient(identity.PublicKey) {
+			continue
+		}
+		return identity, nil
+	}
+
+	if passphraseErr != nil {
+		return nil, errors.Trace(passphraseErr)
+	}
+	if len(tried) > 0 {
+		return nil, errors.Wrapf(ErrNotRecipient, "tried %s", strings.Join(tried, ", "))
+	}
+	return nil, errors.Wrapf(ErrNoIdentity, "tried %s", strings.Join(identityPaths(), ", "))
+}
+
+// readPublicKey returns the public part of a private key without asking for its
+// passphrase. It returns nil if it cannot be known without decrypting the key.
+func readPublicKey(path string, content []byte) ssh.PublicKey {
+	if pub, err := ioutil.ReadFile(path + ".pub"); err == nil {
+		if key, _, _, _, err := ssh.ParseAuthorizedKey(pub); err == nil {
+			return key
+		}
+	}
+
+	signer, err := ssh.ParsePrivateKey(content)
+	if err != nil {
+		if perr, ok := err.(*ssh.PassphraseMissingError); ok {
+			return perr.PublicKey
+		}
+		return nil
+	}
+	return signer.PublicKey()
+}
+
+func cachedIdentity(path string, content []byte) (*Identity, error) {
+	identitiesMu.Lock()
+	defer identitiesMu.Unlock()
+
+	if identity := identities[path]; identity != nil {
+		return identity, nil
+	}
+	identity, err := parseIdentity(path, content)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	identities[path] = identity
+	return identity, nil
+}
+
+func parseIdentity(path string, content []byte) (*Identity, error) {
+	private, err := ssh.ParseRawPrivateKey(content)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, errors.Wrapf(ErrPassphraseRequired, "private key %s", path)
+		}
+		fmt.Fprintf(os.Stderr, "Passphrase for %s: ", path)
+		passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		private, err = ssh.ParseRawPrivateKeyWithPassphrase(content, passphrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read private key %s", path)
+		}
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "cannot read private key %s", path)
+	}
+
+	identity := &Identity{Path: path}
+	switch key := private.(type) {
+	case *ed25519.PrivateKey:
+		identity.private = *key
+	case ed25519.PrivateKey:
+		identity.private = key
+	case *rsa.PrivateKey:
+		identity.private = key
+	default:
+		return nil, errors.Errorf("unsupported private key type in %s, use ed25519 or rsa", path)
+	}
+
+	signer, err := ssh.NewSignerFromKey(identity.private)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	identity.PublicKey = signer.PublicKey()
+
+	return identity, nil
+}
@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"libs.altipla.consulting/errors"
+)
+
+// writeKeys creates the SSH keys of a temporary home directory.
+func writeKeys(t *testing.T, keys map[string]*pem.Block) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, block := range keys {
+		if err := ioutil.WriteFile(filepath.Join(home, ".ssh", name), pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+func ed25519Block(t *testing.T) (*pem.Block, ssh.PublicKey) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, newIdentity(t, private).PublicKey
+}
+
+func rsaBlock(t *testing.T) (*pem.Block, *rsa.PrivateKey) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}, private
+}
+
+func TestLoadIdentityFirstAvailable(t *testing.T) {
+	edBlock, edPublic := ed25519Block(t)
+	rsaKey, _ := rsaBlock(t)
+	writeKeys(t, map[string]*pem.Block{"id_ed25519": edBlock, "id_rsa": rsaKey})
+
+	identity, err := LoadIdentity(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ssh.FingerprintSHA256(identity.PublicKey) != ssh.FingerprintSHA256(edPublic) {
+		t.Errorf("loaded %s, want the ed25519 key", identity.Path)
+	}
+}
+
+func TestLoadIdentityTriesEveryKey(t *testing.T) {
+	edBlock, _ := ed25519Block(t)
+	rsaKey, rsaPrivate := rsaBlock(t)
+	writeKeys(t, map[string]*pem.Block{"id_ed25519": edBlock, "id_rsa": rsaKey})
+
+	recipient := newIdentity(t, rsaPrivate)
+	file := sealed(t, recipient, "FOO=bar\n")
+
+	identity, err := LoadIdentity(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(identity.Path) != "id_rsa" {
+		t.Errorf("loaded %s, want the rsa key that is a recipient", identity.Path)
+	}
+	if _, err := file.Values(identity); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadIdentityNotRecipient(t *testing.T) {
+	edBlock, _ := ed25519Block(t)
+	writeKeys(t, map[string]*pem.Block{"id_ed25519": edBlock})
+
+	file := sealed(t, newEd25519Identity(t), "FOO=bar\n")
+	if _, err := LoadIdentity(file); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("LoadIdentity = %v, want ErrNotRecipient", err)
+	}
+}
+
+func TestLoadIdentityNoKeys(t *testing.T) {
+	writeKeys(t, nil)
+
+	if _, err := LoadIdentity(nil); !errors.Is(err, ErrNoIdentity) {
+		t.Errorf("LoadIdentity = %v, want ErrNoIdentity", err)
+	}
+}
+
+func TestLoadIdentityPassphraseWithoutTerminal(t *testing.T) {
+	rsaKey, rsaPrivate := rsaBlock(t)
+	// Encrypted keys with the legacy PEM format that older SSH versions generated.
+	encrypted, err := x509.EncryptPEMBlock(rand.Reader, rsaKey.Type, rsaKey.Bytes, []byte("secret"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := writeKeys(t, map[string]*pem.Block{"id_rsa": encrypted})
+
+	// Tests run without a terminal in the standard input.
+	file := sealed(t, newIdentity(t, rsaPrivate), "FOO=bar\n")
+	if _, err := LoadIdentity(file); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("LoadIdentity = %v, want ErrPassphraseRequired", err)
+	}
+
+	// Encrypted keys that are not recipients are skipped using the public key
+	// without asking for the passphrase.
+	public := ssh.MarshalAuthorizedKey(newIdentity(t, rsaPrivate).PublicKey)
+	if err := ioutil.WriteFile(filepath.Join(home, ".ssh", "id_rsa.pub"), public, 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := sealed(t, newEd25519Identity(t), "FOO=bar\n")
+	if _, err := LoadIdentity(other); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("LoadIdentity = %v, want ErrNotRecipient", err)
+	}
+}
@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/ssh"
+	"libs.altipla.consulting/errors"
+)
+
+const wrapLabel = "actools-secrets"
+
+// Recipient is a person that can read the secrets with the private key
+// associated to its SSH public key.
+type Recipient struct {
+	// Key is the public key in authorized_keys format.
+	Key string `yaml:"key"`
+
+	// Wrapped is the data key encrypted for this recipient.
+	Wrapped string `yaml:"wrapped"`
+}
+
+// ParseRecipient reads a public key in authorized_keys format.
+func ParseRecipient(line string) (ssh.PublicKey, string, error) {
+	key, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot parse public key")
+	}
+	switch key.Type() {
+	case ssh.KeyAlgoED25519, ssh.KeyAlgoRSA:
+	default:
+		return nil, "", errors.Errorf("unsupported key type %s, use ssh-ed25519 or ssh-rsa", key.Type())
+	}
+	return key, comment, nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the recipient key.
+func (recipient *Recipient) Fingerprint() string {
+	key, _, err := ParseRecipient(recipient.Key)
+	if err != nil {
+		return "invalid"
+	}
+	return ssh.FingerprintSHA256(key)
+}
+
+// Comment returns the comment of the public key, normally the email or host
+// of the recipient.
+func (recipient *Recipient) Comment() string {
+	_, comment, _ := ParseRecipient(recipient.Key)
+	return comment
+}
+
+func newRecipient(key ssh.PublicKey, comment string, dataKey []byte) (*Recipient, error) {
+	wrapped, err := wrap(key, dataKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return &Recipient{
+		Key:     line,
+		Wrapped: base64.StdEncoding.EncodeToString(wrapped),
+	}, nil
+}
+
+func wrap(key ssh.PublicKey, dataKey []byte) ([]byte, error) {
+	crypto, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, errors.Errorf("unsupported key type %s", key.Type())
+	}
+
+	switch pub := crypto.CryptoPublicKey().(type) {
+	case ed25519.PublicKey:
+		point, err := ed25519PublicKeyToX25519(pub)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ephemeral := make([]byte, curve25519.ScalarSize)
+		if _, err := rand.Read(ephemeral); err != nil {
+			return nil, errors.Trace(err)
+		}
+		ephemeralPub, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		shared, err := curve25519.X25519(ephemeral, point)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		aead, err := wrapCipher(shared, ephemeralPub, point)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		nonce := make([]byte, chacha20poly1305.NonceSize)
+		return aead.Seal(ephemeralPub, nonce, dataKey, nil), nil
+
+	case *rsa.PublicKey:
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, dataKey, []byte(wrapLabel))
+		return wrapped, errors.Trace(err)
+	}
+
+	return nil, errors.Errorf("unsupported key type %s", key.Type())
+}
+
+func unwrap(identity *Identity, wrapped []byte) ([]byte, error) {
+	switch priv := identity.private.(type) {
+	case ed25519.PrivateKey:
+		if len(wrapped) < curve25519.PointSize {
+			return nil, errors.Errorf("wrapped key too short")
+		}
+		point, err := ed25519PublicKeyToX25519(priv.Public().(ed25519.PublicKey))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ephemeralPub := wrapped[:curve25519.PointSize]
+		shared, err := curve25519.X25519(ed25519PrivateKeyToX25519(priv), ephemeralPub)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		aead, err := wrapCipher(shared, ephemeralPub, point)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		nonce := make([]byte, chacha20poly1305.NonceSize)
+		dataKey, err := aead.Open(nil, nonce, wrapped[curve25519.PointSize:], nil)
+		return dataKey, errors.Trace(err)
+
+	case *rsa.PrivateKey:
+		dataKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, wrapped, []byte(wrapLabel))
+		return dataKey, errors.Trace(err)
+	}
+
+	return nil, errors.Errorf("unsupported identity")
+}
+
+// wrapCipher derives the key that protects the data key from the shared secret
+// and both public keys. Each wrap uses a new ephemeral key so the nonce can be
+// fixed.
+func wrapCipher(shared, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(wrapLabel)), key); err != nil {
+		return nil, errors.Trace(err)
+	}
+	aead, err := chacha20poly1305.New(key)
+	return aead, errors.Trace(err)
+}
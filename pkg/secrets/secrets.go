@@ -0,0 +1,308 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v2"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/actools/pkg/manifests"
+)
+
+const Filename = "actools.secrets"
+
+const header = "# Encrypted with `actools secrets`. DO NOT EDIT.\n\n"
+
+var (
+	ErrNoIdentity   = errors.New("no private key available to decrypt the secrets")
+	ErrNotRecipient = errors.New("the private key is not a recipient of the secrets")
+
+	// ErrPassphraseRequired is returned when the private key is encrypted and
+	// there is no terminal to ask for the passphrase.
+	ErrPassphraseRequired = errors.New("the private key is protected with a passphrase and there is no terminal to ask for it")
+)
+
+// File is the encrypted content of the secrets. The content is encrypted with a
+// random data key and that key is stored once for every recipient encrypted
+// with their public key.
+type File struct {
+	Recipients []*Recipient `yaml:"recipients"`
+	Data       string       `yaml:"data"`
+}
+
+func Exists() (bool, error) {
+	if _, err := os.Stat(Filename); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+func Load() (*File, error) {
+	content, err := ioutil.ReadFile(Filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	file := new(File)
+	if err := yaml.Unmarshal(content, file); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", Filename)
+	}
+	return file, nil
+}
+
+// New prepares empty secrets with the identity as the only recipient.
+func New(identity *Identity) (*File, error) {
+	dataKey, err := newDataKey()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	recipient, err := newRecipient(identity.PublicKey, "", dataKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	file := &File{
+		Recipients: []*Recipient{recipient},
+	}
+	if err := file.seal(dataKey, nil); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return file, nil
+}
+
+func newDataKey() ([]byte, error) {
+	dataKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return dataKey, nil
+}
+
+func (file *File) Save() error {
+	content, err := yaml.Marshal(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.Write(content)
+
+	return errors.Trace(ioutil.WriteFile(Filename, buf.Bytes(), 0644))
+}
+
+// hasRecipient returns true if the public key is one of the recipients.
+func (file *File) hasRecipient(key ssh.PublicKey) bool {
+	for _, recipient := range file.Recipients {
+		rkey, _, err := ParseRecipient(recipient.Key)
+		if err == nil && bytes.Equal(rkey.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (file *File) dataKey(identity *Identity) ([]byte, error) {
+	own := identity.PublicKey.Marshal()
+	for _, recipient := range file.Recipients {
+		key, _, err := ParseRecipient(recipient.Key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !bytes.Equal(key.Marshal(), own) {
+			continue
+		}
+
+		wrapped, err := base64.StdEncoding.DecodeString(recipient.Wrapped)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		dataKey, err := unwrap(identity, wrapped)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decrypt the data key with %s", identity.Path)
+		}
+		return dataKey, nil
+	}
+
+	return nil, errors.Wrapf(ErrNotRecipient, "key %s", ssh.FingerprintSHA256(identity.PublicKey))
+}
+
+// Plaintext decrypts the content of the secrets.
+func (file *File) Plaintext(identity *Identity) ([]byte, error) {
+	dataKey, err := file.dataKey(identity)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if file.Data == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	aead, err := chacha20poly1305.NewX(dataKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.Errorf("encrypted data too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decrypt %s", Filename)
+	}
+	return plaintext, nil
+}
+
+// Values decrypts and parses the KEY=VALUE lines of the secrets.
+func (file *File) Values(identity *Identity) (map[string]string, error) {
+	plaintext, err := file.Plaintext(identity)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pairs, err := manifests.ParseEnv(plaintext)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	values := make(map[string]string)
+	for _, pair := range pairs {
+		values[pair[0]] = pair[1]
+	}
+	return values, nil
+}
+
+// Seal replaces the content of the secrets keeping the data key.
+func (file *File) Seal(identity *Identity, plaintext []byte) error {
+	if _, err := manifests.ParseEnv(plaintext); err != nil {
+		return errors.Trace(err)
+	}
+
+	dataKey, err := file.dataKey(identity)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(file.seal(dataKey, plaintext))
+}
+
+func (file *File) seal(dataKey, plaintext []byte) error {
+	aead, err := chacha20poly1305.NewX(dataKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.Trace(err)
+	}
+	file.Data = base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+	return nil
+}
+
+// AddRecipient gives access to the secrets to a new public key. The identity
+// must be already a recipient.
+func (file *File) AddRecipient(identity *Identity, key ssh.PublicKey, comment string) error {
+	for _, recipient := range file.Recipients {
+		existing, _, err := ParseRecipient(recipient.Key)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if bytes.Equal(existing.Marshal(), key.Marshal()) {
+			return errors.Errorf("%s is already a recipient", ssh.FingerprintSHA256(key))
+		}
+	}
+
+	dataKey, err := file.dataKey(identity)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	recipient, err := newRecipient(key, comment, dataKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	file.Recipients = append(file.Recipients, recipient)
+
+	return nil
+}
+
+// Rotate encrypts the content again with a new data key for the recipients,
+// removing first the ones whose fingerprints are listed. Removed recipients
+// could have kept a copy of the old values, so the credentials themselves
+// should be changed too.
+func (file *File) Rotate(identity *Identity, remove []string) error {
+	plaintext, err := file.Plaintext(identity)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	dataKey, err := newDataKey()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	removed := make(map[string]bool)
+	for _, fingerprint := range remove {
+		removed[fingerprint] = false
+	}
+	var recipients []*Recipient
+	for _, recipient := range file.Recipients {
+		key, comment, err := ParseRecipient(recipient.Key)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, ok := removed[ssh.FingerprintSHA256(key)]; ok {
+			removed[ssh.FingerprintSHA256(key)] = true
+			continue
+		}
+
+		rotated, err := newRecipient(key, comment, dataKey)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		recipients = append(recipients, rotated)
+	}
+	for fingerprint, found := range removed {
+		if !found {
+			return errors.Errorf("recipient not found: %s", fingerprint)
+		}
+	}
+	if len(recipients) == 0 {
+		return errors.Errorf("cannot remove all the recipients of the secrets")
+	}
+
+	file.Recipients = recipients
+	return errors.Trace(file.seal(dataKey, plaintext))
+}
+
+// Env decrypts the secrets of the project with the identity of the user. It
+// returns nil if the project has no secrets.
+func Env() (map[string]string, error) {
+	if exists, err := Exists(); err != nil {
+		return nil, errors.Trace(err)
+	} else if !exists {
+		return nil, nil
+	}
+
+	file, err := Load()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	identity, err := LoadIdentity(file)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	values, err := file.Values(identity)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return values, nil
+}
@@ -0,0 +1,175 @@
+package secrets
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"libs.altipla.consulting/errors"
+)
+
+func newEd25519Identity(t *testing.T) *Identity {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newIdentity(t, private)
+}
+
+func newRSAIdentity(t *testing.T) *Identity {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newIdentity(t, private)
+}
+
+func newIdentity(t *testing.T, private interface{}) *Identity {
+	t.Helper()
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Identity{Path: "test", PublicKey: signer.PublicKey(), private: private}
+}
+
+func sealed(t *testing.T, identity *Identity, plaintext string) *File {
+	t.Helper()
+	file, err := New(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Seal(identity, []byte(plaintext)); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity func(t *testing.T) *Identity
+	}{
+		{"ed25519", newEd25519Identity},
+		{"rsa", newRSAIdentity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity := test.identity(t)
+			file := sealed(t, identity, "FOO=bar\nBAZ=qux\n")
+
+			values, err := file.Values(identity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if values["FOO"] != "bar" || values["BAZ"] != "qux" || len(values) != 2 {
+				t.Errorf("unexpected values: %v", values)
+			}
+		})
+	}
+}
+
+func TestWrongRecipient(t *testing.T) {
+	identity := newEd25519Identity(t)
+	file := sealed(t, identity, "FOO=bar\n")
+
+	for _, other := range []*Identity{newEd25519Identity(t), newRSAIdentity(t)} {
+		if _, err := file.Plaintext(other); !errors.Is(err, ErrNotRecipient) {
+			t.Errorf("Plaintext with %s = %v, want ErrNotRecipient", other.PublicKey.Type(), err)
+		}
+	}
+}
+
+func TestWrappedKeyForAnotherRecipient(t *testing.T) {
+	identity := newEd25519Identity(t)
+	other := newEd25519Identity(t)
+	file := sealed(t, identity, "FOO=bar\n")
+
+	// Pretend to be the recipient with the data key wrapped for someone else.
+	file.Recipients[0].Key = string(ssh.MarshalAuthorizedKey(other.PublicKey))
+	if _, err := file.Plaintext(other); err == nil {
+		t.Fatal("expected error unwrapping the key of another recipient")
+	}
+}
+
+func TestTamperedData(t *testing.T) {
+	identity := newEd25519Identity(t)
+	file := sealed(t, identity, "FOO=bar\n")
+
+	data, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0x01
+	file.Data = base64.StdEncoding.EncodeToString(data)
+
+	if _, err := file.Plaintext(identity); err == nil {
+		t.Fatal("expected error decrypting tampered data")
+	}
+}
+
+func TestTamperedWrappedKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity func(t *testing.T) *Identity
+	}{
+		{"ed25519", newEd25519Identity},
+		{"rsa", newRSAIdentity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity := test.identity(t)
+			file := sealed(t, identity, "FOO=bar\n")
+
+			wrapped, err := base64.StdEncoding.DecodeString(file.Recipients[0].Wrapped)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wrapped[len(wrapped)-1] ^= 0x01
+			file.Recipients[0].Wrapped = base64.StdEncoding.EncodeToString(wrapped)
+
+			if _, err := file.Plaintext(identity); err == nil {
+				t.Fatal("expected error unwrapping a tampered data key")
+			}
+		})
+	}
+}
+
+func TestAddRecipientAndRotate(t *testing.T) {
+	identity := newEd25519Identity(t)
+	other := newRSAIdentity(t)
+	file := sealed(t, identity, "FOO=bar\n")
+
+	if err := file.AddRecipient(identity, other.PublicKey, "other@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddRecipient(identity, other.PublicKey, ""); err == nil {
+		t.Error("expected error adding a repeated recipient")
+	}
+	plaintext, err := file.Plaintext(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "FOO=bar\n" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+
+	if err := file.Rotate(identity, []string{ssh.FingerprintSHA256(other.PublicKey)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Plaintext(other); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("removed recipient: %v, want ErrNotRecipient", err)
+	}
+	if _, err := file.Plaintext(identity); err != nil {
+		t.Errorf("remaining recipient: %v", err)
+	}
+
+	if err := file.Rotate(identity, []string{ssh.FingerprintSHA256(identity.PublicKey)}); err == nil {
+		t.Error("expected error removing all the recipients")
+	}
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"math/big"
+
+	"libs.altipla.consulting/errors"
+)
+
+// curve25519P is the prime of the field 2^255 - 19.
+var curve25519P, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+// ed25519PublicKeyToX25519 converts the Edwards point of a public key to its
+// Montgomery u coordinate: u = (1 + y) / (1 - y).
+func ed25519PublicKeyToX25519(pub ed25519.PublicKey) ([]byte, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.Errorf("invalid ed25519 public key size: %d", len(pub))
+	}
+
+	// The key is y in little endian with the sign of x in the highest bit.
+	le := make([]byte, len(pub))
+	copy(le, pub)
+	le[31] &= 0x7f
+	y := new(big.Int).SetBytes(reverse(le))
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, errors.Errorf("invalid ed25519 public key")
+	}
+
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curve25519P)
+	if denominator.Sign() == 0 {
+		return nil, errors.Errorf("invalid ed25519 public key")
+	}
+	denominator.ModInverse(denominator, curve25519P)
+
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, denominator)
+	u.Mod(u, curve25519P)
+
+	out := make([]byte, 32)
+	u.FillBytes(out)
+	return reverse(out), nil
+}
+
+// ed25519PrivateKeyToX25519 derives the scalar that ed25519 uses internally
+// from the seed of the private key.
+func ed25519PrivateKeyToX25519(priv ed25519.PrivateKey) []byte {
+	h := sha512.Sum512(priv.Seed())
+	scalar := h[:32]
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return scalar
+}
+
+func reverse(b []byte) []byte {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
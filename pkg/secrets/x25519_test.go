@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestEd25519ToX25519(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		converted, err := ed25519PublicKeyToX25519(public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		derived, err := curve25519.X25519(ed25519PrivateKeyToX25519(private), curve25519.Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(converted, derived) {
+			t.Fatalf("converted public key %x does not match the private one %x", converted, derived)
+		}
+	}
+}
+
+func TestEd25519ToX25519Invalid(t *testing.T) {
+	if _, err := ed25519PublicKeyToX25519(ed25519.PublicKey([]byte{1, 2, 3})); err == nil {
+		t.Error("expected error with a short public key")
+	}
+
+	// The neutral point y = 1 has no Montgomery equivalent.
+	neutral := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	neutral[0] = 1
+	if _, err := ed25519PublicKeyToX25519(neutral); err == nil {
+		t.Error("expected error with the neutral point")
+	}
+}
@@ -1,11 +1,11 @@
 package update
 
 import (
+	"encoding/json"
 	"io/ioutil"
-	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -14,63 +14,176 @@ import (
 	"github.com/altipla-consulting/actools/pkg/config"
 )
 
+const checkInterval = 1 * time.Hour
+
+// ErrOutdated is returned by Check when the running version is lower than the
+// minimum allowed and it should be updated before continuing.
+var ErrOutdated = errors.New("actools is not updated")
+
+// checkResult is stored between runs because the check runs in the background
+// and its result is shown the next time the user runs a command.
+type checkResult struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Channel   string    `json:"channel"`
+	Manifest  *Manifest `json:"manifest,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func checkFilename() string {
+	return filepath.Join(config.Home(), ".actools", "update-check.json")
+}
+
+// Check reports the result of the last version check and starts a new one in
+// the background if it is too old. It only fails if the version is lower than
+// the minimum of the manifest.
 func Check() error {
-	// Jenkins y el entorno de desarrollo de actools no deben comprobar la versión
-	if config.Development() || config.Jenkins() {
+	// Jenkins, el entorno de desarrollo de actools y el modo offline no deben comprobar la versión
+	if config.Development() || config.Jenkins() || config.Offline() {
 		return nil
 	}
 
-	lastUpdateFilename := filepath.Join(config.Home(), ".actools", "last-update-check.txt")
-
-	if err := os.MkdirAll(filepath.Dir(lastUpdateFilename), 0700); err != nil {
+	result, err := readCheckResult()
+	if err != nil {
 		return errors.Trace(err)
 	}
 
-	lastUpdate := time.Time{}
-	lastUpdateContent, err := ioutil.ReadFile(lastUpdateFilename)
-	if err != nil && !os.IsNotExist(err) {
-		return errors.Trace(err)
-	} else if err == nil {
-		if err := lastUpdate.UnmarshalText(lastUpdateContent); err != nil {
-			return errors.Trace(err)
-		}
+	// Results of other channels are not valid after the user switches.
+	if result.Channel != config.Channel() {
+		result = new(checkResult)
 	}
 
-	if time.Now().Sub(lastUpdate) > 1*time.Hour {
-		reply, err := http.Get("https://tools.altipla.consulting/version-manifest/actools")
-		if err != nil {
-			return errors.Trace(err)
+	if time.Since(result.CheckedAt) > checkInterval {
+		if err := startBackgroundCheck(result); err != nil {
+			log.WithField("error", err.Error()).Warning("Cannot check for new versions of actools")
 		}
-		defer reply.Body.Close()
+	}
 
-		content, err := ioutil.ReadAll(reply.Body)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		version := strings.TrimSpace(string(content))
+	if result.Error != "" {
+		log.WithField("error", result.Error).Warning("Cannot check for new versions of actools")
+		return nil
+	}
+	if result.Manifest == nil {
+		return nil
+	}
 
-		if version != config.Version {
-			log.WithFields(log.Fields{"current": config.Version, "latest": version}).Error("actools is not updated")
+	belowMinimum, err := olderThan(config.Version, result.Manifest.Minimum)
+	if err != nil {
+		log.WithField("error", err.Error()).Warning("Cannot compare the version of actools")
+		return nil
+	}
+	if belowMinimum {
+		log.WithFields(log.Fields{
+			"current": config.Version,
+			"minimum": result.Manifest.Minimum,
+		}).Error("actools is too old and should be updated")
+		printUpdateInstructions()
+		return ErrOutdated
+	}
 
-			log.Warning()
-			log.Warning("Run the following command to install the latest version:")
-			log.Warning()
-			log.Warning("\tcurl https://tools.altipla.consulting/install/actools | bash")
-			log.Warning()
+	outdated, err := olderThan(config.Version, result.Manifest.Latest)
+	if err != nil {
+		log.WithField("error", err.Error()).Warning("Cannot compare the version of actools")
+		return nil
+	}
+	if outdated {
+		log.WithFields(log.Fields{
+			"current": config.Version,
+			"latest":  result.Manifest.Latest,
+			"channel": config.Channel(),
+		}).Warning("New version of actools available")
+		printUpdateInstructions()
+	}
 
-			os.Exit(2)
+	return nil
+}
 
-			return nil
-		}
+// Refresh downloads the manifest and stores the result for the next run. Network
+// failures are stored too to warn the user without blocking any command.
+func Refresh() error {
+	result := &checkResult{
+		CheckedAt: time.Now(),
+		Channel:   config.Channel(),
+	}
+	manifest, err := FetchManifest(config.Channel())
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Manifest = manifest
+	}
 
-		lastUpdateContent, err = time.Now().MarshalText()
-		if err != nil {
-			return errors.Trace(err)
-		}
-		if err := ioutil.WriteFile(lastUpdateFilename, lastUpdateContent, 0600); err != nil {
-			return errors.Trace(err)
+	content, err := json.Marshal(result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(checkFilename()), 0700); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(ioutil.WriteFile(checkFilename(), content, 0600))
+}
+
+// IsOutdated compares the running version with the latest one of the manifest.
+func IsOutdated(manifest *Manifest) (bool, error) {
+	outdated, err := olderThan(config.Version, manifest.Latest)
+	return outdated, errors.Trace(err)
+}
+
+func readCheckResult() (*checkResult, error) {
+	result := new(checkResult)
+	content, err := ioutil.ReadFile(checkFilename())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
 		}
+		return nil, errors.Trace(err)
 	}
 
-	return nil
+	// A corrupted file is not important, the next check will replace it.
+	if err := json.Unmarshal(content, result); err != nil {
+		log.WithField("error", err.Error()).Debug("Cannot read the last update check")
+		return new(checkResult), nil
+	}
+
+	return result, nil
+}
+
+func startBackgroundCheck(last *checkResult) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// Mark the check as started to avoid running multiple ones concurrently. The
+	// background process will replace it with the result.
+	pending, err := json.Marshal(&checkResult{
+		CheckedAt: time.Now(),
+		Channel:   config.Channel(),
+		Manifest:  last.Manifest,
+		Error:     last.Error,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(checkFilename()), 0700); err != nil {
+		return errors.Trace(err)
+	}
+	if err := ioutil.WriteFile(checkFilename(), pending, 0600); err != nil {
+		return errors.Trace(err)
+	}
+
+	log.Debug("Start background update check")
+	cmd := exec.Command(exe, "update", "check")
+	if err := cmd.Start(); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(cmd.Process.Release())
+}
+
+func printUpdateInstructions() {
+	log.Warning()
+	log.Warning("Run the following command to install the latest version:")
+	log.Warning()
+	log.Warning("\tactools update")
+	log.Warning()
 }
@@ -0,0 +1,187 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"libs.altipla.consulting/errors"
+)
+
+// Install downloads the latest binary of a release channel, verifies it is the
+// expected version and replaces the running executable. The replaced binary is
+// kept for Rollback.
+func Install(channel, version string) error {
+	name := "/bin/actools-" + channel
+	binary, err := fetch(downloadClient, name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	checksum, err := fetch(downloadClient, name+".sha256")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	signature, err := fetch(downloadClient, name+".release.sig")
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if err := verify(releaseStatement(channel, version, binary), binary, checksum, signature); err != nil {
+		return errors.Trace(err)
+	}
+
+	exe, err := executable()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := backup(exe); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(replace(exe, bytes.NewReader(binary)))
+}
+
+// Rollback restores the binary replaced by the last update. The current one is
+// kept in its place to undo the rollback if needed.
+func Rollback() error {
+	exe, err := executable()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	previous, err := ioutil.ReadFile(previousFilename(exe))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("there is no previous version to rollback to")
+		}
+		return errors.Trace(err)
+	}
+
+	if err := backup(exe); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(replace(exe, bytes.NewReader(previous)))
+}
+
+// releaseStatement is the message signed by the release pipeline. It binds the
+// binary to its version and channel, so an older signed build served in place
+// of the latest one does not pass the verification.
+func releaseStatement(channel, version string, binary []byte) []byte {
+	sum := sha256.Sum256(binary)
+	return []byte(fmt.Sprintf("actools-%s %s %x\n", channel, version, sum))
+}
+
+func verify(statement, binary, checksum, signature []byte) error {
+	// The checksum file has the format of the sha256sum tool.
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 {
+		return errors.Errorf("empty checksum file")
+	}
+	expected, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	actual := sha256.Sum256(binary)
+	if !bytes.Equal(expected, actual[:]) {
+		return errors.Errorf("checksum mismatch of the downloaded binary: expected %x, got %x", expected, actual)
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ed25519.Verify(key, statement, signature) {
+		return errors.Errorf("invalid signature of the downloaded binary, it may not be the expected version")
+	}
+
+	return nil
+}
+
+func parsePublicKey(content string) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode([]byte(content))
+	if block == nil {
+		return nil, errors.Errorf("cannot decode the public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	edkey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("unexpected public key type: %T", key)
+	}
+
+	return edkey, nil
+}
+
+// executable resolves the path of the running binary. Tests replace it to
+// update a temporary file instead.
+var executable = currentExecutable
+
+func currentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	return exe, nil
+}
+
+func previousFilename(exe string) string {
+	return exe + ".previous"
+}
+
+func backup(exe string) error {
+	log.WithField("path", previousFilename(exe)).Debug("Backup current binary")
+
+	f, err := os.Open(exe)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+
+	return errors.Trace(writeAtomic(previousFilename(exe), f))
+}
+
+func replace(exe string, r io.Reader) error {
+	log.WithField("path", exe).Debug("Replace binary")
+	return errors.Trace(writeAtomic(exe, r))
+}
+
+// writeAtomic writes a temporary file in the same directory and renames it
+// over the destination, so no one can observe a partially written binary.
+func writeAtomic(dest string, r io.Reader) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), ".actools-update-")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.Trace(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(os.Rename(tmp.Name(), dest))
+}
@@ -0,0 +1,206 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type release struct {
+	binary    []byte
+	checksum  []byte
+	signature []byte
+}
+
+func signedRelease(key ed25519.PrivateKey, version string, binary []byte) *release {
+	sum := sha256.Sum256(binary)
+	return &release{
+		binary:    binary,
+		checksum:  []byte(hex.EncodeToString(sum[:]) + "  actools-stable\n"),
+		signature: ed25519.Sign(key, releaseStatement("stable", version, binary)),
+	}
+}
+
+// setupInstall serves the release from a local server, trusts a new signing key
+// and points the executable to a temporary file with the current binary.
+func setupInstall(t *testing.T) (ed25519.PrivateKey, string, func(*release)) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalKey := publicKey
+	publicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	t.Cleanup(func() { publicKey = originalKey })
+
+	exe := filepath.Join(t.TempDir(), "actools")
+	if err := ioutil.WriteFile(exe, []byte("current"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	originalExecutable := executable
+	executable = func() (string, error) { return exe, nil }
+	t.Cleanup(func() { executable = originalExecutable })
+
+	var served *release
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bin/actools-stable":
+			w.Write(served.binary)
+		case "/bin/actools-stable.sha256":
+			w.Write(served.checksum)
+		case "/bin/actools-stable.release.sig":
+			w.Write(served.signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("ACTOOLS_UPDATE_SERVER", server.URL)
+
+	return priv, exe, func(r *release) { served = r }
+}
+
+func readFile(t *testing.T, filename string) string {
+	t.Helper()
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestInstall(t *testing.T) {
+	key, exe, serve := setupInstall(t)
+	serve(signedRelease(key, "2.0.0", []byte("new")))
+
+	if err := Install("stable", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, exe); got != "new" {
+		t.Errorf("binary = %q, want %q", got, "new")
+	}
+	if got := readFile(t, previousFilename(exe)); got != "current" {
+		t.Errorf("previous binary = %q, want %q", got, "current")
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("binary mode = %v, want 0755", info.Mode().Perm())
+	}
+}
+
+func TestInstallBadChecksum(t *testing.T) {
+	key, exe, serve := setupInstall(t)
+	r := signedRelease(key, "2.0.0", []byte("new"))
+	r.binary = []byte("tampered")
+	serve(r)
+
+	if err := Install("stable", "2.0.0"); err == nil {
+		t.Fatal("expected checksum error")
+	}
+
+	if got := readFile(t, exe); got != "current" {
+		t.Errorf("binary = %q, want it untouched", got)
+	}
+	if _, err := os.Stat(previousFilename(exe)); !os.IsNotExist(err) {
+		t.Errorf("unexpected backup of the binary: %v", err)
+	}
+}
+
+func TestInstallBadSignature(t *testing.T) {
+	key, exe, serve := setupInstall(t)
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := signedRelease(key, "2.0.0", []byte("new"))
+	r.signature = ed25519.Sign(other, releaseStatement("stable", "2.0.0", r.binary))
+	serve(r)
+
+	if err := Install("stable", "2.0.0"); err == nil {
+		t.Fatal("expected signature error")
+	}
+
+	if got := readFile(t, exe); got != "current" {
+		t.Errorf("binary = %q, want it untouched", got)
+	}
+	if _, err := os.Stat(previousFilename(exe)); !os.IsNotExist(err) {
+		t.Errorf("unexpected backup of the binary: %v", err)
+	}
+}
+
+func TestInstallOlderSignedVersion(t *testing.T) {
+	key, exe, serve := setupInstall(t)
+	serve(signedRelease(key, "1.0.0", []byte("old")))
+
+	if err := Install("stable", "2.0.0"); err == nil {
+		t.Fatal("expected signature error installing an older version")
+	}
+
+	if got := readFile(t, exe); got != "current" {
+		t.Errorf("binary = %q, want it untouched", got)
+	}
+}
+
+func TestInstallOtherChannel(t *testing.T) {
+	key, exe, serve := setupInstall(t)
+	r := signedRelease(key, "2.0.0", []byte("beta"))
+	r.signature = ed25519.Sign(key, releaseStatement("beta", "2.0.0", r.binary))
+	serve(r)
+
+	if err := Install("stable", "2.0.0"); err == nil {
+		t.Fatal("expected signature error installing the build of another channel")
+	}
+
+	if got := readFile(t, exe); got != "current" {
+		t.Errorf("binary = %q, want it untouched", got)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	key, exe, serve := setupInstall(t)
+	serve(signedRelease(key, "2.0.0", []byte("new")))
+
+	if err := Install("stable", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, exe); got != "current" {
+		t.Errorf("binary = %q, want %q", got, "current")
+	}
+	if got := readFile(t, previousFilename(exe)); got != "new" {
+		t.Errorf("previous binary = %q, want %q to undo the rollback", got, "new")
+	}
+}
+
+func TestRollbackWithoutPrevious(t *testing.T) {
+	_, exe, _ := setupInstall(t)
+
+	if err := Rollback(); err == nil {
+		t.Fatal("expected error without a previous version")
+	}
+
+	if got := readFile(t, exe); got != "current" {
+		t.Errorf("binary = %q, want it untouched", got)
+	}
+}
@@ -0,0 +1,7 @@
+package update
+
+// publicKey verifies the signatures of the released binaries. The private part
+// is only available to the release pipeline. Tests replace it with their own key.
+var publicKey = `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEAz9r6iYFRfSNbVmN3GiebjoZU3VXYgFWdfJzoVGEhzqM=
+-----END PUBLIC KEY-----`
@@ -0,0 +1,45 @@
+package update
+
+import (
+	"encoding/json"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+type Manifest struct {
+	// Latest is the last version released.
+	Latest string `json:"latest"`
+
+	// Minimum is the oldest version allowed to run. Lower versions should be
+	// updated before continuing.
+	Minimum string `json:"minimum,omitempty"`
+}
+
+// FetchManifest downloads the versions of a release channel.
+func FetchManifest(channel string) (*Manifest, error) {
+	content, err := fetch(manifestClient, "/version-manifest/actools-"+channel)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return parseManifest(content)
+}
+
+func parseManifest(content []byte) (*Manifest, error) {
+	// Old manifests only contained the latest version in plain text.
+	trimmed := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(trimmed, "{") {
+		return &Manifest{Latest: trimmed}, nil
+	}
+
+	manifest := new(Manifest)
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if manifest.Latest == "" {
+		return nil, errors.Errorf("manifest without latest version")
+	}
+
+	return manifest, nil
+}
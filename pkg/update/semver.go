@@ -0,0 +1,124 @@
+package update
+
+import (
+	"strconv"
+	"strings"
+
+	"libs.altipla.consulting/errors"
+)
+
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseSemver accepts versions with or without the "v" prefix and the refs of
+// the tags that GitHub injects in the releases.
+func parseSemver(s string) (semver, error) {
+	original := s
+	s = strings.TrimPrefix(s, "refs/tags/")
+	s = strings.TrimPrefix(s, "v")
+
+	// Build metadata does not participate in the comparisons.
+	if idx := strings.Index(s, "+"); idx != -1 {
+		s = s[:idx]
+	}
+
+	var v semver
+	if idx := strings.Index(s, "-"); idx != -1 {
+		v.prerelease = s[idx+1:]
+		s = s[:idx]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return semver{}, errors.Errorf("invalid version: %s", original)
+	}
+	numbers := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, errors.Errorf("invalid version: %s", original)
+		}
+		*numbers[i] = n
+	}
+
+	return v, nil
+}
+
+// compare returns -1, 0 or 1 if a is lower, equal or greater than b.
+func (a semver) compare(b semver) int {
+	if c := compareInt(a.major, b.major); c != 0 {
+		return c
+	}
+	if c := compareInt(a.minor, b.minor); c != 0 {
+		return c
+	}
+	if c := compareInt(a.patch, b.patch); c != 0 {
+		return c
+	}
+
+	// A prerelease has lower precedence than the normal version.
+	switch {
+	case a.prerelease == b.prerelease:
+		return 0
+	case a.prerelease == "":
+		return 1
+	case b.prerelease == "":
+		return -1
+	}
+	return comparePrerelease(a.prerelease, b.prerelease)
+}
+
+func comparePrerelease(a, b string) int {
+	aparts := strings.Split(a, ".")
+	bparts := strings.Split(b, ".")
+	for i := 0; i < len(aparts) && i < len(bparts); i++ {
+		an, aerr := strconv.Atoi(aparts[i])
+		bn, berr := strconv.Atoi(bparts[i])
+		switch {
+		case aerr == nil && berr == nil:
+			if c := compareInt(an, bn); c != 0 {
+				return c
+			}
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aparts[i], bparts[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(aparts), len(bparts))
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// olderThan returns true if the version is lower than the reference. Empty
+// references are never newer.
+func olderThan(version, reference string) (bool, error) {
+	if reference == "" {
+		return false, nil
+	}
+
+	v, err := parseSemver(version)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	r, err := parseSemver(reference)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	return v.compare(r) < 0, nil
+}
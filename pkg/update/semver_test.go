@@ -0,0 +1,160 @@
+package update
+
+import (
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		input string
+		want  semver
+	}{
+		{"1.2.3", semver{major: 1, minor: 2, patch: 3}},
+		{"v1.2.3", semver{major: 1, minor: 2, patch: 3}},
+		{"refs/tags/v1.2.3", semver{major: 1, minor: 2, patch: 3}},
+		{"1.2", semver{major: 1, minor: 2}},
+		{"1", semver{major: 1}},
+		{"1.2.3-rc.1", semver{major: 1, minor: 2, patch: 3, prerelease: "rc.1"}},
+		{"1.2.3+build.5", semver{major: 1, minor: 2, patch: 3}},
+		{"1.2.3-beta+build.5", semver{major: 1, minor: 2, patch: 3, prerelease: "beta"}},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := parseSemver(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("parseSemver(%q) = %+v, want %+v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSemverInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"dev",
+		"v",
+		"1.2.3.4",
+		"1.x.3",
+		"1.-2.3",
+		"1..3",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if v, err := parseSemver(test); err == nil {
+				t.Errorf("parseSemver(%q) = %+v, want error", test, v)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3+build", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.3.0", "1.2.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.10.0", "1.9.0", 1},
+
+		// Prerelease ordering from the semver spec.
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.1", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.a+" vs "+test.b, func(t *testing.T) {
+			a, err := parseSemver(test.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := parseSemver(test.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := a.compare(b); got != test.want {
+				t.Errorf("compare(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+			}
+			if got := b.compare(a); got != -test.want {
+				t.Errorf("compare(%q, %q) = %d, want %d", test.b, test.a, got, -test.want)
+			}
+		})
+	}
+}
+
+func TestComparePrerelease(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"1", "alpha", -1},
+		{"alpha", "1", 1},
+		{"alpha", "beta", -1},
+		{"rc.1", "rc.1.1", -1},
+		{"rc.2", "rc.1.1", 1},
+		{"alpha", "alpha", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.a+" vs "+test.b, func(t *testing.T) {
+			if got := comparePrerelease(test.a, test.b); got != test.want {
+				t.Errorf("comparePrerelease(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOlderThan(t *testing.T) {
+	tests := []struct {
+		version, reference string
+		want               bool
+	}{
+		{"1.2.3", "1.2.4", true},
+		{"1.2.4", "1.2.3", false},
+		{"1.2.3", "1.2.3", false},
+		{"1.2.3-rc.1", "1.2.3", true},
+		{"v1.2.3", "refs/tags/v1.3.0", true},
+		{"1.2.3", "", false},
+		{"dev", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.version+" vs "+test.reference, func(t *testing.T) {
+			got, err := olderThan(test.version, test.reference)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("olderThan(%q, %q) = %v, want %v", test.version, test.reference, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOlderThanInvalid(t *testing.T) {
+	tests := []struct {
+		version, reference string
+	}{
+		{"dev", "1.2.3"},
+		{"1.2.3", "latest"},
+	}
+	for _, test := range tests {
+		t.Run(test.version+" vs "+test.reference, func(t *testing.T) {
+			if _, err := olderThan(test.version, test.reference); err == nil {
+				t.Errorf("olderThan(%q, %q) should fail", test.version, test.reference)
+			}
+		})
+	}
+}
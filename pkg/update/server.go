@@ -0,0 +1,48 @@
+package update
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"libs.altipla.consulting/errors"
+)
+
+const defaultServer = "https://tools.altipla.consulting"
+
+var (
+	// manifestClient fails fast to avoid blocking the user if the server is down.
+	manifestClient = &http.Client{Timeout: 10 * time.Second}
+
+	// downloadClient has room enough to download the whole binary in slow connections.
+	downloadClient = &http.Client{Timeout: 5 * time.Minute}
+)
+
+// server returns the base URL of the releases. It can be replaced with
+// ACTOOLS_UPDATE_SERVER to use a local stand-in.
+func server() string {
+	if s := os.Getenv("ACTOOLS_UPDATE_SERVER"); s != "" {
+		return s
+	}
+	return defaultServer
+}
+
+func fetch(client *http.Client, path string) ([]byte, error) {
+	reply, err := client.Get(server() + path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer reply.Body.Close()
+
+	if reply.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status fetching %s: %s", path, reply.Status)
+	}
+
+	content, err := ioutil.ReadAll(reply.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return content, nil
+}